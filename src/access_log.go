@@ -0,0 +1,129 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const commonLogTimeFormat = "02/Jan/2006:15:04:05 -0700"
+
+var accessLogFormats = []string{"off", "common", "combined", "json"}
+
+type accessLogEntry struct {
+	Time       string  `json:"time"`
+	RemoteAddr string  `json:"remote_addr"`
+	ClientIp   string  `json:"client_ip"`
+	Method     string  `json:"method"`
+	Uri        string  `json:"uri"`
+	Proto      string  `json:"proto"`
+	Status     int     `json:"status"`
+	Bytes      int     `json:"bytes"`
+	LatencyMs  float64 `json:"latency_ms"`
+	Referer    string  `json:"referer"`
+	UserAgent  string  `json:"user_agent"`
+	RequestId  string  `json:"request_id"`
+}
+
+func validateAccessLogFormat(format string) error {
+	if !slices.Contains(accessLogFormats, format) {
+		return fmt.Errorf("unknown access log format '%s', use one of '%s'", format, strings.Join(accessLogFormats, "', '"))
+	}
+	return nil
+}
+
+// isAccessLogged reports if requests to the path show up in the access log. Excluded paths ending
+// with '/' exclude all paths below them.
+func (appConfig *appConfig) isAccessLogged(path string) bool {
+	if appConfig.accessLogFormat == "off" {
+		return false
+	}
+	for _, excludedPath := range appConfig.accessLogExcludedPaths {
+		if path == excludedPath || (strings.HasSuffix(excludedPath, "/") && strings.HasPrefix(path, excludedPath)) {
+			return false
+		}
+	}
+	return true
+}
+
+func (appConfig *appConfig) hideAccessLogPath(path string) {
+	if !slices.Contains(appConfig.accessLogExcludedPaths, path) {
+		appConfig.accessLogExcludedPaths = append(appConfig.accessLogExcludedPaths, path)
+	}
+}
+
+func (appConfig *appConfig) showAccessLogPath(path string) {
+	appConfig.accessLogExcludedPaths = slices.DeleteFunc(slices.Clone(appConfig.accessLogExcludedPaths), func(excludedPath string) bool {
+		return excludedPath == path
+	})
+}
+
+// accessLog writes one line per request in the configured format to the output of the logs.
+func (s *server) accessLog(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.config.isAccessLogged(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w, statusCode: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+
+		requestId, _ := r.Context().Value(requestIdContextKey).(string)
+		clientIp := stripPort(r.RemoteAddr)
+		if clientInfo, ok := r.Context().Value(clientInfoContextKey).(*clientInfo); ok {
+			clientIp = clientInfo.ip
+		}
+		entry := &accessLogEntry{
+			Time:       start.Format(time.RFC3339),
+			RemoteAddr: r.RemoteAddr,
+			ClientIp:   clientIp,
+			Method:     r.Method,
+			Uri:        r.RequestURI,
+			Proto:      r.Proto,
+			Status:     recorder.statusCode,
+			Bytes:      recorder.bytesWritten,
+			LatencyMs:  float64(time.Since(start).Microseconds()) / 1000,
+			Referer:    r.Referer(),
+			UserAgent:  r.UserAgent(),
+			RequestId:  requestId,
+		}
+		_, err := fmt.Fprintln(log.StandardLogger().Out, entry.format(s.config.accessLogFormat, start))
+		if err != nil {
+			serverLog.Errorf("error on writing the access log: %s", err)
+		}
+	})
+}
+
+func (e *accessLogEntry) format(format string, start time.Time) string {
+	bytes := "-"
+	if e.Bytes > 0 {
+		bytes = strconv.Itoa(e.Bytes)
+	}
+	common := fmt.Sprintf("%s - - [%s] \"%s %s %s\" %d %s", e.ClientIp, start.Format(commonLogTimeFormat), e.Method, e.Uri, e.Proto, e.Status, bytes)
+	switch format {
+	case "combined":
+		return fmt.Sprintf("%s %q %q", common, orDash(e.Referer), orDash(e.UserAgent))
+	case "json":
+		line, err := json.Marshal(e)
+		if err != nil {
+			return common
+		}
+		return string(line)
+	default:
+		return common
+	}
+}
+
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
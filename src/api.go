@@ -0,0 +1,320 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"runtime"
+	"sort"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+type runtimeView struct {
+	ProcessId     int    `json:"processId"`
+	UserId        int    `json:"userId"`
+	Hostname      string `json:"hostname"`
+	StartTime     string `json:"startTime"`
+	UptimeSeconds int    `json:"uptimeSeconds"`
+	Goroutines    int    `json:"goroutines"`
+}
+
+type configView struct {
+	ConfigFilePath       string        `json:"configFilePath"`
+	ConfigFiles          []string      `json:"configFiles"`
+	Port                 int           `json:"port"`
+	ManagementPort       int           `json:"managementPort"`
+	TlsPort              int           `json:"tlsPort"`
+	TlsCertFile          string        `json:"tlsCertFile"`
+	TlsKeyFile           string        `json:"tlsKeyFile"`
+	TlsSelfSigned        bool          `json:"tlsSelfSigned"`
+	TlsSANs              []string      `json:"tlsSANs"`
+	TlsClientAuth        string        `json:"tlsClientAuth"`
+	TlsClientCAFile      string        `json:"tlsClientCAFile"`
+	RequestCAFile        string        `json:"requestCAFile"`
+	H2cEnabled           bool          `json:"h2cEnabled"`
+	Http3Enabled         bool          `json:"http3Enabled"`
+	GrpcPort             int           `json:"grpcPort"`
+	SocketListeners      []string      `json:"socketListeners"`
+	ProxyProtocol        bool          `json:"proxyProtocol"`
+	TrustedProxies       []string      `json:"trustedProxies"`
+	Ready                bool          `json:"ready"`
+	Alive                bool          `json:"alive"`
+	Started              bool          `json:"started"`
+	RootEnabled          bool          `json:"rootEnabled"`
+	RootDelaySeconds     int           `json:"rootDelaySeconds"`
+	StartUpDelaySeconds  int           `json:"startUpDelaySeconds"`
+	StartUpProgress      int           `json:"startUpProgressSeconds"`
+	TearDownDelaySeconds int           `json:"tearDownDelaySeconds"`
+	DrainTimeoutSeconds  int           `json:"drainTimeoutSeconds"`
+	Name                 string        `json:"name"`
+	Version              string        `json:"version"`
+	Message              string        `json:"message"`
+	Color                string        `json:"color"`
+	LogToFileOnly        bool          `json:"logToFileOnly"`
+	LogFormat            string        `json:"logFormat"`
+	LogLevel             string        `json:"logLevel"`
+	AccessLog            string        `json:"accessLog"`
+	AccessLogExcluded    []string      `json:"accessLogExcludedPaths"`
+	NetworkModes         []string      `json:"networkModes"`
+	EchoMaxBodyBytes     int           `json:"echoMaxBodyBytes"`
+	ToolboxEnabled       bool          `json:"toolboxEnabled"`
+	TemplatePath         string        `json:"templatePath"`
+	PersistMetaInfo      bool          `json:"persistMetaInfo"`
+	CatImageUrl          string        `json:"catImageUrl"`
+	RevealSecrets        bool          `json:"revealSecrets"`
+	Secrets              []*secretInfo `json:"secrets"`
+	Runtime              *runtimeView  `json:"runtime"`
+}
+
+type configChange struct {
+	Old any `json:"old"`
+	New any `json:"new"`
+}
+
+// metricUpdate either sets the value of a custom metric or ramps it from 'from' to 'to' within 'duration'.
+type metricUpdate struct {
+	Value    *float64 `json:"value"`
+	From     *float64 `json:"from"`
+	To       *float64 `json:"to"`
+	Duration string   `json:"duration"`
+}
+
+type apiError struct {
+	Error string `json:"error"`
+}
+
+func (appConfig *appConfig) view() *configView {
+	hostname, _ := os.Hostname()
+	return &configView{
+		ConfigFilePath:       appConfig.configFilePath,
+		ConfigFiles:          appConfig.configFiles,
+		Port:                 appConfig.applicationPort,
+		ManagementPort:       appConfig.managementPort,
+		TlsPort:              appConfig.tlsPort,
+		TlsCertFile:          appConfig.tlsCertFile,
+		TlsKeyFile:           appConfig.tlsKeyFile,
+		TlsSelfSigned:        appConfig.tlsSelfSigned,
+		TlsSANs:              appConfig.tlsSANs,
+		TlsClientAuth:        appConfig.tlsClientAuth,
+		TlsClientCAFile:      appConfig.tlsClientCAFile,
+		RequestCAFile:        appConfig.requestCAFile,
+		H2cEnabled:           appConfig.h2cEnabled,
+		Http3Enabled:         appConfig.http3Enabled,
+		GrpcPort:             appConfig.grpcPort,
+		SocketListeners:      appConfig.socketListenerNames(),
+		ProxyProtocol:        appConfig.proxyProtocol,
+		TrustedProxies:       appConfig.trustedProxyNames(),
+		Ready:                appConfig.ready,
+		Alive:                appConfig.alive,
+		Started:              appConfig.started,
+		RootEnabled:          appConfig.rootEnabled,
+		RootDelaySeconds:     appConfig.rootDelaySeconds,
+		StartUpDelaySeconds:  appConfig.startUpDelaySeconds,
+		StartUpProgress:      appConfig.startUpProgressSeconds,
+		TearDownDelaySeconds: appConfig.tearDownDelaySeconds,
+		DrainTimeoutSeconds:  appConfig.drainTimeoutSeconds,
+		Name:                 appConfig.redact("name", appConfig.applicationName),
+		Version:              appConfig.redact("version", appConfig.applicationVersion),
+		Message:              appConfig.redact("message", appConfig.applicationMessage),
+		Color:                appConfig.redact("color", appConfig.color),
+		LogToFileOnly:        appConfig.logToFileOnly,
+		LogFormat:            appConfig.logFormat,
+		LogLevel:             appConfig.logLevel,
+		AccessLog:            appConfig.accessLogFormat,
+		AccessLogExcluded:    appConfig.accessLogExcludedPaths,
+		NetworkModes:         appConfig.networkModeNames(),
+		EchoMaxBodyBytes:     appConfig.echoMaxBodyBytes,
+		ToolboxEnabled:       appConfig.toolboxEnabled,
+		TemplatePath:         appConfig.templatePath,
+		PersistMetaInfo:      appConfig.persistMetaInfo,
+		CatImageUrl:          appConfig.catImageUrl,
+		RevealSecrets:        appConfig.revealSecrets,
+		Secrets:              appConfig.readSecrets(),
+		Runtime: &runtimeView{
+			ProcessId:     os.Getpid(),
+			UserId:        os.Getuid(),
+			Hostname:      hostname,
+			StartTime:     startTime.Format(time.RFC3339),
+			UptimeSeconds: int(time.Since(startTime).Seconds()),
+			Goroutines:    runtime.NumGoroutine(),
+		},
+	}
+}
+
+// patch applies the given runtime-mutable fields to the configuration. Either all fields are applied or,
+// if any field is unknown, immutable or invalid, none of them.
+func (appConfig *appConfig) patch(fields map[string]json.RawMessage) (map[string]*configChange, error) {
+
+	ready, alive, rootEnabled := appConfig.ready, appConfig.alive, appConfig.rootEnabled
+	rootDelaySeconds := appConfig.rootDelaySeconds
+	logFormat, logLevel, accessLogFormat := appConfig.logFormat, appConfig.logLevel, appConfig.accessLogFormat
+
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		var err error
+		switch name {
+		case "ready":
+			err = json.Unmarshal(fields[name], &ready)
+		case "alive":
+			err = json.Unmarshal(fields[name], &alive)
+		case "rootEnabled":
+			err = json.Unmarshal(fields[name], &rootEnabled)
+		case "rootDelaySeconds":
+			err = json.Unmarshal(fields[name], &rootDelaySeconds)
+			if err == nil && rootDelaySeconds < 0 {
+				err = fmt.Errorf("must not be negative")
+			}
+		case "logFormat":
+			err = json.Unmarshal(fields[name], &logFormat)
+			if err == nil {
+				_, err = newLogFormatter(logFormat)
+			}
+		case "logLevel":
+			err = json.Unmarshal(fields[name], &logLevel)
+			if err == nil {
+				_, err = log.ParseLevel(logLevel)
+			}
+		case "accessLog":
+			err = json.Unmarshal(fields[name], &accessLogFormat)
+			if err == nil {
+				err = validateAccessLogFormat(accessLogFormat)
+			}
+		default:
+			return nil, fmt.Errorf("field '%s' cannot be changed at runtime", name)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for field '%s': %s", name, err)
+		}
+	}
+
+	changes := map[string]*configChange{}
+	if ready != appConfig.ready {
+		changes["ready"] = &configChange{Old: appConfig.ready, New: ready}
+		appConfig.ready = ready
+	}
+	if alive != appConfig.alive {
+		changes["alive"] = &configChange{Old: appConfig.alive, New: alive}
+		appConfig.alive = alive
+	}
+	if rootEnabled != appConfig.rootEnabled {
+		changes["rootEnabled"] = &configChange{Old: appConfig.rootEnabled, New: rootEnabled}
+		appConfig.rootEnabled = rootEnabled
+	}
+	if rootDelaySeconds != appConfig.rootDelaySeconds {
+		changes["rootDelaySeconds"] = &configChange{Old: appConfig.rootDelaySeconds, New: rootDelaySeconds}
+		appConfig.rootDelaySeconds = rootDelaySeconds
+	}
+	if logFormat != appConfig.logFormat {
+		changes["logFormat"] = &configChange{Old: appConfig.logFormat, New: logFormat}
+		appConfig.logFormat = logFormat
+		_ = setLogFormat(logFormat)
+	}
+	if logLevel != appConfig.logLevel {
+		changes["logLevel"] = &configChange{Old: appConfig.logLevel, New: logLevel}
+		appConfig.logLevel = logLevel
+		_ = setLogLevel(logLevel)
+	}
+	if accessLogFormat != appConfig.accessLogFormat {
+		changes["accessLog"] = &configChange{Old: appConfig.accessLogFormat, New: accessLogFormat}
+		appConfig.accessLogFormat = accessLogFormat
+	}
+	return changes, nil
+}
+
+func (s *server) handleGetConfig(w http.ResponseWriter, r *http.Request) {
+	requestLog(r).Info("Request to config endpoint ('GET /api/config')")
+	writeJSON(w, http.StatusOK, s.config.view())
+}
+
+func (s *server) handlePatchConfig(w http.ResponseWriter, r *http.Request) {
+	requestLog(r).Info("Request to config endpoint ('PATCH /api/config')")
+
+	var fields map[string]json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&fields); err != nil {
+		writeJSON(w, http.StatusBadRequest, &apiError{Error: fmt.Sprintf("invalid request body: %s", err)})
+		return
+	}
+
+	changes, err := s.config.patch(fields)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, &apiError{Error: err.Error()})
+		return
+	}
+	for name, change := range changes {
+		requestLog(r).Infof("Changed '%s' from '%v' to '%v' via the config endpoint", name, change.Old, change.New)
+	}
+	history.record("http", changes)
+	writeJSON(w, http.StatusOK, changes)
+}
+
+func (s *server) handleGetConfigHistory(w http.ResponseWriter, r *http.Request) {
+	requestLog(r).Info("Request to config history endpoint ('GET /api/config/history')")
+
+	if history == nil {
+		writeJSON(w, http.StatusNotFound, &apiError{Error: "config history is only available with 'persistMetaInfo' enabled"})
+		return
+	}
+	records, err := readConfigHistory()
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, &apiError{Error: err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, records)
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(value); err != nil {
+		serverLog.Errorf("error on writing json response: %s", err)
+	}
+}
+
+func (s *server) handleGetMetrics(w http.ResponseWriter, r *http.Request) {
+	requestLog(r).Info("Request to metrics endpoint ('GET /api/metrics')")
+	writeJSON(w, http.StatusOK, s.metrics.custom.list())
+}
+
+func (s *server) handlePutMetric(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	requestLog(r).Infof("Request to metrics endpoint ('PUT /api/metrics/%s')", name)
+
+	update := &metricUpdate{}
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(update); err != nil {
+		writeJSON(w, http.StatusBadRequest, &apiError{Error: fmt.Sprintf("invalid request body: %s", err)})
+		return
+	}
+
+	var err error
+	switch {
+	case update.Value != nil && update.From == nil && update.To == nil && update.Duration == "":
+		err = s.metrics.custom.set(name, *update.Value)
+	case update.Value == nil && update.From != nil && update.To != nil && update.Duration != "":
+		var duration time.Duration
+		duration, err = time.ParseDuration(update.Duration)
+		if err == nil {
+			err = s.metrics.custom.ramp(name, *update.From, *update.To, duration)
+		}
+	default:
+		err = fmt.Errorf("either 'value' or 'from', 'to' and 'duration' have to be set")
+	}
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, &apiError{Error: err.Error()})
+		return
+	}
+	requestLog(r).Infof("Updated metric '%s' via the metrics endpoint", name)
+	writeJSON(w, http.StatusOK, s.metrics.custom.list())
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"bufio"
+	"crypto/x509"
 	"fmt"
 	"io"
 	"net/http"
@@ -10,19 +11,45 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
-
-	log "github.com/sirupsen/logrus"
 )
 
+var leakedMemEntries atomic.Int64
+var leakedCpuGoroutines atomic.Int64
+
 type cli struct {
-	config *appConfig
+	config     *appConfig
+	server     *server
+	metrics    *metrics
+	requestCAs atomic.Pointer[x509.CertPool]
+}
+
+func newCli(appConfig *appConfig, server *server) *cli {
+	cli := &cli{
+		config:  appConfig,
+		server:  server,
+		metrics: server.metrics,
+	}
+	cli.loadRequestCAs()
+	go watchFiles(func() []string { return []string{appConfig.requestCAFile} }, cli.loadRequestCAs)
+	return cli
 }
 
-func newCli(appConfig *appConfig) *cli {
-	return &cli{
-		appConfig,
+// loadRequestCAs loads the CAs trusted by the 'request' command, the system CAs if no 'requestCAFile' is set.
+// A broken CA bundle is logged and the current one stays in use.
+func (cli *cli) loadRequestCAs() {
+	if cli.config.requestCAFile == "" {
+		cli.requestCAs.Store(nil)
+		return
 	}
+	requestCAs, err := loadCertPool(cli.config.requestCAFile)
+	if err != nil {
+		cliLog.Errorf("error on loading the CAs for requests, keeping the current ones: %s", err)
+		return
+	}
+	cli.requestCAs.Store(requestCAs)
+	cliLog.Infof("Loaded the CAs for requests from '%s'", cli.config.requestCAFile)
 }
 
 func createHelpText() string {
@@ -31,6 +58,7 @@ func createHelpText() string {
 	sb.WriteString("\thelp:                get info about available commands and endpoints\n")
 	sb.WriteString("\tinit:                set readiness true, liveness true and delay 0\n")
 	sb.WriteString("\tconfig:              print out the current application configuration\n")
+	sb.WriteString("\tconfig history:      print out the persisted configuration changes\n")
 	sb.WriteString("\tset ready:           application readiness probe will be successful\n")
 	sb.WriteString("\tset unready:         application readiness probe will fail\n")
 	sb.WriteString("\tset alive:           application liveness probe will be successful\n")
@@ -38,11 +66,43 @@ func createHelpText() string {
 	sb.WriteString("\tleak mem:            leak memory\n")
 	sb.WriteString("\tleak cpu:            leak cpu\n")
 	sb.WriteString("\trequest <url>:       request a url, eg 'request https://www.kubermatic.com/'\n")
+	sb.WriteString("\trequest --http1.1|--http2|--http3 <url>:\n")
+	sb.WriteString("\t                     request a url forcing the protocol version, eg 'request --http3 https://localhost:8443/'\n")
 	sb.WriteString("\tdelay / <seconds>:   set delay for the root endpoint ('/') in seconds, eg 'delay / 5'\n")
+	sb.WriteString("\tset metric <name> <value>:\n")
+	sb.WriteString("\t                     publish a custom gauge on '/metrics', eg 'set metric queue_depth 10'\n")
+	sb.WriteString("\tramp metric <name> <from> <to> <duration>:\n")
+	sb.WriteString("\t                     change a custom gauge linearly over time, eg 'ramp metric queue_depth 0 100 5m'\n")
+	sb.WriteString("\tlog level <level>:   set the log level, eg 'log level debug'\n")
+	sb.WriteString("\tlog format <format>: set the log format to 'text', 'logfmt' or 'json', eg 'log format json'\n")
+	sb.WriteString("\taccess log <format>: set the access log format to 'off', 'common', 'combined' or 'json', eg 'access log combined'\n")
+	sb.WriteString("\taccess log hide <path>:\n")
+	sb.WriteString("\t                     exclude requests to the path from the access log, eg 'access log hide /readiness'\n")
+	sb.WriteString("\taccess log show <path>:\n")
+	sb.WriteString("\t                     include requests to the path in the access log again, eg 'access log show /readiness'\n")
+	sb.WriteString("\tlistener stop:       close the listener of the application port, new connections are refused\n")
+	sb.WriteString("\tlistener start:      open the listener of the application port again\n")
+	sb.WriteString("\tlistener rebind <port>:\n")
+	sb.WriteString("\t                     move the listener of the application to another port, eg 'listener rebind 8081'\n")
+	sb.WriteString("\tnetwork <path> throttle <bytes per second>|slow <duration>|stall <duration>|reset:\n")
+	sb.WriteString("\t                     shape the responses of the path, eg 'network /bytes/* throttle 1024' or 'network / stall 10s'\n")
+	sb.WriteString("\tnetwork <path> off:  send the responses of the path unshaped again\n")
+	sb.WriteString("\treveal secrets:      show the values of secrets in the configuration, the logs and the root endpoint\n")
+	sb.WriteString("\thide secrets:        redact the values of secrets\n")
 	sb.WriteString("\table Endpoints:\n")
 	sb.WriteString("\t/:                   root endpoint, the output is depending on the application configuration\n")
+	sb.WriteString("\t/echo:               echoes the request including the body as text or json\n")
+	sb.WriteString("\t/status/<code>, /delay/<duration>, /bytes/<n>, /stream/<n>, /redirect/<n>, /headers, /ip, /cookies:\n")
+	sb.WriteString("\t                     httpbin-like toolbox endpoints, only available if 'toolboxEnabled' is set\n")
+	sb.WriteString("\tserved on 'managementPort' if configured:\n")
+	sb.WriteString("\t/startup:            startup probe\n")
 	sb.WriteString("\t/liveness:           liveness probe\n")
 	sb.WriteString("\t/readiness:          readiness probe\n")
+	sb.WriteString("\t/metrics:            metrics in prometheus exposition format\n")
+	sb.WriteString("\t/api/config:         effective configuration as json (GET), change runtime fields (PATCH)\n")
+	sb.WriteString("\t/api/config/history: persisted configuration changes as json\n")
+	sb.WriteString("\t/api/metrics:        custom metrics as json (GET), set or ramp a custom metric via '/api/metrics/<name>' (PUT)\n")
+	sb.WriteString("\t/debug/pprof/:       go profiling data, only available on 'managementPort'\n")
 	return sb.String()
 }
 
@@ -51,13 +111,20 @@ func (cli *cli) handleStdin() {
 	for {
 		text, err := reader.ReadString('\n')
 		if err != nil {
-			log.Errorf("error on reading from stdin: '%s'", err)
+			cliLog.Errorf("error on reading from stdin: '%s'", err)
 		}
 		text = strings.ReplaceAll(text, "\n", "")
 		if text != "" {
+			before := cli.config.snapshot()
 			err = cli.executeCommand(text)
 			if err != nil {
-				log.Errorf("error on handling command '%s': %s", text, err)
+				cliLog.Errorf("error on handling command '%s': %s", text, err)
+			}
+			if text == "init" {
+				history.recordDiff("init", before, cli.config.snapshot())
+				history.recordEnvOverrides(cli.config)
+			} else {
+				history.recordDiff("cli", before, cli.config.snapshot())
 			}
 		}
 	}
@@ -66,36 +133,50 @@ func (cli *cli) handleStdin() {
 func (cli *cli) executeCommand(command string) error {
 
 	if command == "help" {
-		log.Info(createHelpText())
+		cliLog.Info(createHelpText())
 	} else if command == "init" {
-		log.Info("Re-initializing the application configuration")
+		cliLog.Info("Re-initializing the application configuration")
 		cli.config.initAppConfig(true)
 		cli.config.ready = true
-		log.Info(cli.config)
+		cliLog.Info(cli.config)
 	} else if command == "config" {
-		log.Info(cli.config)
+		cliLog.Info(cli.config)
+	} else if command == "config history" {
+		if history == nil {
+			return fmt.Errorf("config history is only available with 'persistMetaInfo' enabled")
+		}
+		records, err := readConfigHistory()
+		if err != nil {
+			return fmt.Errorf("error on reading the config history: %s", err)
+		}
+		cliLog.Info(configHistoryString(records))
 	} else if command == "set ready" {
 		cli.config.ready = true
-		log.Info("Set the application to ready")
+		cliLog.Info("Set the application to ready")
 	} else if command == "set unready" {
 		cli.config.ready = false
-		log.Info("Set the application to unready")
+		cliLog.Info("Set the application to unready")
 	} else if command == "set alive" {
 		cli.config.alive = true
-		log.Info("Set the application to alive")
+		cliLog.Info("Set the application to alive")
 	} else if command == "set dead" {
 		cli.config.alive = false
-		log.Info("Set the application to dead")
+		cliLog.Info("Set the application to dead")
 	} else if command == "leak mem" {
-		log.Info("Leaking Memory")
+		cliLog.Info("Leaking Memory")
 		leakMem()
 	} else if command == "leak cpu" {
-		log.Info("Leaking CPU")
+		cliLog.Info("Leaking CPU")
 		leakCpu()
 	} else if strings.HasPrefix(command, "request ") {
 		url, _ := strings.CutPrefix(command, "request ")
-		log.Infof("Requesting URL '%s'", url)
-		err := request(url)
+		protocol := ""
+		if strings.HasPrefix(url, "--") {
+			protocol, url, _ = strings.Cut(url, " ")
+			url = strings.TrimSpace(url)
+		}
+		cliLog.Infof("Requesting URL '%s'", url)
+		err := cli.request(protocol, url)
 		if err != nil {
 			return fmt.Errorf("error on requesting URL '%s': %s", url, err)
 		}
@@ -106,32 +187,149 @@ func (cli *cli) executeCommand(command string) error {
 		if err != nil {
 			return fmt.Errorf("error on converting delay string '%s' to int: %s", delayString, err)
 		}
-		log.Infof("Set delay for the root endpoint ('/') to '%d' seconds", cli.config.rootDelaySeconds)
+		cliLog.Infof("Set delay for the root endpoint ('/') to '%d' seconds", cli.config.rootDelaySeconds)
+	} else if strings.HasPrefix(command, "set metric ") {
+		args := strings.Fields(strings.TrimPrefix(command, "set metric "))
+		if len(args) != 2 {
+			return fmt.Errorf("expected 'set metric <name> <value>'")
+		}
+		value, err := strconv.ParseFloat(args[1], 64)
+		if err != nil {
+			return fmt.Errorf("error on converting value '%s' to float: %s", args[1], err)
+		}
+		if err := cli.metrics.custom.set(args[0], value); err != nil {
+			return err
+		}
+		cliLog.Infof("Set metric '%s' to %v", args[0], value)
+	} else if strings.HasPrefix(command, "ramp metric ") {
+		args := strings.Fields(strings.TrimPrefix(command, "ramp metric "))
+		if len(args) != 4 {
+			return fmt.Errorf("expected 'ramp metric <name> <from> <to> <duration>'")
+		}
+		from, err := strconv.ParseFloat(args[1], 64)
+		if err != nil {
+			return fmt.Errorf("error on converting value '%s' to float: %s", args[1], err)
+		}
+		to, err := strconv.ParseFloat(args[2], 64)
+		if err != nil {
+			return fmt.Errorf("error on converting value '%s' to float: %s", args[2], err)
+		}
+		duration, err := time.ParseDuration(args[3])
+		if err != nil {
+			return fmt.Errorf("error on converting duration '%s': %s", args[3], err)
+		}
+		if err := cli.metrics.custom.ramp(args[0], from, to, duration); err != nil {
+			return err
+		}
+		cliLog.Infof("Ramping metric '%s' from %v to %v within %s", args[0], from, to, duration)
+	} else if strings.HasPrefix(command, "log level ") {
+		level, _ := strings.CutPrefix(command, "log level ")
+		if err := setLogLevel(level); err != nil {
+			return fmt.Errorf("error on setting the log level: %s", err)
+		}
+		cli.config.logLevel = level
+		cliLog.Infof("Set the log level to '%s'", level)
+	} else if strings.HasPrefix(command, "log format ") {
+		format, _ := strings.CutPrefix(command, "log format ")
+		if err := setLogFormat(format); err != nil {
+			return fmt.Errorf("error on setting the log format: %s", err)
+		}
+		cli.config.logFormat = format
+		cliLog.Infof("Set the log format to '%s'", format)
+	} else if strings.HasPrefix(command, "access log hide ") {
+		path, _ := strings.CutPrefix(command, "access log hide ")
+		cli.config.hideAccessLogPath(path)
+		cliLog.Infof("Excluded requests to '%s' from the access log", path)
+	} else if strings.HasPrefix(command, "access log show ") {
+		path, _ := strings.CutPrefix(command, "access log show ")
+		cli.config.showAccessLogPath(path)
+		cliLog.Infof("Included requests to '%s' in the access log", path)
+	} else if strings.HasPrefix(command, "access log ") {
+		format, _ := strings.CutPrefix(command, "access log ")
+		if err := validateAccessLogFormat(format); err != nil {
+			return err
+		}
+		cli.config.accessLogFormat = format
+		cliLog.Infof("Set the access log format to '%s'", format)
+	} else if command == "listener stop" {
+		if err := cli.server.stopListener(); err != nil {
+			return fmt.Errorf("error on stopping the listener: %s", err)
+		}
+		_, port := cli.server.listening()
+		cliLog.Infof("Stopped listening on port %d, new connections are refused", port)
+	} else if command == "listener start" {
+		_, port := cli.server.listening()
+		if err := cli.server.startListener(port); err != nil {
+			return fmt.Errorf("error on starting the listener: %s", err)
+		}
+		cliLog.Infof("Listening on port %d again", port)
+	} else if strings.HasPrefix(command, "listener rebind ") {
+		portString, _ := strings.CutPrefix(command, "listener rebind ")
+		port, err := strconv.Atoi(portString)
+		if err != nil || port < 1 || port > 65535 {
+			return fmt.Errorf("invalid port '%s'", portString)
+		}
+		if err := cli.server.rebindListener(port); err != nil {
+			return fmt.Errorf("error on rebinding the listener: %s", err)
+		}
+		cliLog.Infof("Listening on port %d", port)
+	} else if strings.HasPrefix(command, "network ") {
+		args := strings.Fields(strings.TrimPrefix(command, "network "))
+		if len(args) < 2 || len(args) > 3 {
+			return fmt.Errorf("expected 'network <path> <mode> [<param>]'")
+		}
+		if args[1] == "off" {
+			cli.config.setNetworkMode(args[0], nil)
+			cliLog.Infof("Removed the network mode of '%s'", args[0])
+			return nil
+		}
+		param := ""
+		if len(args) == 3 {
+			param = args[2]
+		}
+		networkMode, err := newNetworkMode(args[0], args[1], param)
+		if err != nil {
+			return err
+		}
+		cli.config.setNetworkMode(args[0], networkMode)
+		cliLog.Infof("Set the network mode '%s'", networkMode)
+	} else if command == "reveal secrets" {
+		cli.config.revealSecrets = true
+		cliLog.Info("Revealing the values of secrets")
+	} else if command == "hide secrets" {
+		cli.config.revealSecrets = false
+		cliLog.Info("Redacting the values of secrets")
 	} else if strings.HasPrefix(command, "disable /") {
 		cli.config.rootEnabled = false
-		log.Info("Disabled the root endpoint ('/')")
+		cliLog.Info("Disabled the root endpoint ('/')")
 	} else if strings.HasPrefix(command, "enable /") {
 		cli.config.rootEnabled = true
-		log.Info("Enabled the root endpoint ('/')")
+		cliLog.Info("Enabled the root endpoint ('/')")
 	} else {
 		return fmt.Errorf("unknown command '%s'", command)
 	}
 	return nil
 }
 
-func request(url string) error {
-	log.Infof("Request '%s'", url)
-	resp, err := http.Get(url)
+func (cli *cli) request(protocol, url string) error {
+	cliLog.Infof("Request '%s'", url)
+	transport, err := cli.newRequestTransport(protocol)
+	if err != nil {
+		return err
+	}
+	client := &http.Client{Transport: transport}
+	defer client.CloseIdleConnections()
+	resp, err := client.Get(url)
 	if err != nil {
 		return err
 	}
 	defer func() {
 		if err := resp.Body.Close(); err != nil {
-			log.Errorf("error on closing: %v", err)
+			cliLog.Errorf("error on closing: %v", err)
 		}
 	}()
 
-	log.Info(newResponseInfo(resp))
+	cliLog.Info(newResponseInfo(resp))
 
 	bodyBytes, err := io.ReadAll(resp.Body)
 	if err != nil {
@@ -141,7 +339,7 @@ func request(url string) error {
 	if len(bodyString) >= 100 {
 		bodyString = bodyString[:100]
 	}
-	log.Infof("Response Body: \n%s", bodyString)
+	cliLog.Infof("Response Body: \n%s", bodyString)
 	return nil
 }
 
@@ -152,14 +350,15 @@ func leakMem() {
 		if count%1000 == 0 {
 			var m runtime.MemStats
 			runtime.ReadMemStats(&m)
-			log.Infof("Alloc = %v MiB", m.Alloc/1024/1024)
-			log.Infof("\tTotalAlloc = %v MiB", m.TotalAlloc/1024/1024)
-			log.Infof("\tSys = %v MiB", m.Sys/1024/1024)
-			log.Infof("\tNumGC = %v\n", m.NumGC)
+			cliLog.Infof("Alloc = %v MiB", m.Alloc/1024/1024)
+			cliLog.Infof("\tTotalAlloc = %v MiB", m.TotalAlloc/1024/1024)
+			cliLog.Infof("\tSys = %v MiB", m.Sys/1024/1024)
+			cliLog.Infof("\tNumGC = %v\n", m.NumGC)
 		}
 		time.Sleep(time.Nanosecond)
 		count++
 		memLeak = append(memLeak, "THIS IS A MEM LEAK") //nolint:staticcheck
+		leakedMemEntries.Add(1)
 	}
 }
 
@@ -175,6 +374,7 @@ func leakCpu() {
 
 		// Spawn a new goroutine every second
 		waitGroup.Add(1)
+		leakedCpuGoroutines.Add(1)
 		go cpuIntensiveTask(&waitGroup, numGoroutines+1)
 	}
 }
@@ -193,7 +393,7 @@ func cpuIntensiveTask(waitGroup *sync.WaitGroup, id int) {
 
 // writer, err := os.Open(os.DevNull)
 // if err != nil {
-// 	log.Errorf("error on opening /dev/null: %s", err)
+// 	cliLog.Errorf("error on opening /dev/null: %s", err)
 // 	return err
 // }
 // defer writer.Close()
@@ -206,10 +406,10 @@ func cpuIntensiveTask(waitGroup *sync.WaitGroup, id int) {
 // 			var usage syscall.Rusage
 // 			err = syscall.Getrusage(syscall.RUSAGE_SELF, &usage)
 // 			if err != nil {
-// 				log.Errorf("error on cpu usage: %s", err)
+// 				cliLog.Errorf("error on cpu usage: %s", err)
 // 			}
-// 			log.Infof("User CPU Time: %v\n", usage.Utime)
-// 			log.Infof("System CPU Time: %v\n", usage.Stime)
+// 			cliLog.Infof("User CPU Time: %v\n", usage.Utime)
+// 			cliLog.Infof("System CPU Time: %v\n", usage.Stime)
 // 			fmt.Fprintf(writer, ".")
 // 		}
 // 	}()
@@ -5,51 +5,141 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"os"
+	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
+	"sync"
+	"text/template"
 
 	"github.com/magiconair/properties"
-	log "github.com/sirupsen/logrus"
 )
 
+var expansionPattern = regexp.MustCompile(`\$\{([^}:]+)(?::-([^}]*))?\}`)
+
 type appConfig struct {
-	configFilePath       string
-	applicationPort      int
-	alive                bool
-	ready                bool
-	rootEnabled          bool
-	rootDelaySeconds     int
-	startUpDelaySeconds  int
-	tearDownDelaySeconds int
-	applicationName      string
-	applicationVersion   string
-	applicationMessage   string
-	color                string
-	logToFileOnly        bool
-	persistMetaInfo      bool
-	catImageUrl          string
+	configFilePath         string
+	configFiles            []string
+	applicationPort        int
+	managementPort         int
+	tlsPort                int
+	tlsCertFile            string
+	tlsKeyFile             string
+	tlsSelfSigned          bool
+	tlsSANs                []string
+	tlsClientAuth          string
+	tlsClientCAFile        string
+	requestCAFile          string
+	h2cEnabled             bool
+	http3Enabled           bool
+	grpcPort               int
+	socketListeners        []*socketListener
+	proxyProtocol          bool
+	trustedProxies         []*net.IPNet
+	networkModes           []*networkMode
+	alive                  bool
+	ready                  bool
+	started                bool
+	rootEnabled            bool
+	rootDelaySeconds       int
+	startUpDelaySeconds    int
+	startUpProgressSeconds int
+	tearDownDelaySeconds   int
+	drainTimeoutSeconds    int
+	applicationName        string
+	applicationVersion     string
+	applicationMessage     string
+	color                  string
+	logToFileOnly          bool
+	logFormat              string
+	logLevel               string
+	accessLogFormat        string
+	accessLogExcludedPaths []string
+	echoMaxBodyBytes       int
+	toolboxEnabled         bool
+	templatePath           string
+	persistMetaInfo        bool
+	catImageUrl            string
+	revealSecrets          bool
+	secretFiles            []*secretFile
+	secretProperties       map[string]bool
+	valueTemplates         map[string]*template.Template
+	envOverrides           map[string]*configChange
+	mapsMutex              sync.RWMutex
+	loading                *configLoading
+}
+
+// configLoading collects the properties read from file references and overridden by environment variables while
+// the configuration is loaded. Requests read these maps concurrently, so they are only published once complete.
+type configLoading struct {
+	secretProperties map[string]bool
+	envOverrides     map[string]*configChange
+}
+
+// redact masks the values of properties read from a file reference while loading, regardless of the reveal mode.
+func (loading *configLoading) redact(property, value string) string {
+	if loading.secretProperties[property] {
+		return redactedValue
+	}
+	return value
 }
 
 func (appConfig *appConfig) String() string {
 	var sb strings.Builder
 	sb.WriteString("Application Configuration: \n")
 	sb.WriteString(fmt.Sprintf("\tconfigFilePath:         %v\n", appConfig.configFilePath))
+	sb.WriteString(fmt.Sprintf("\tconfigFiles:            %v\n", strings.Join(appConfig.configFiles, ", ")))
 	sb.WriteString(fmt.Sprintf("\tport:                   %d\n", appConfig.applicationPort))
+	sb.WriteString(fmt.Sprintf("\tmanagementPort:         %d\n", appConfig.managementPort))
+	sb.WriteString(fmt.Sprintf("\ttlsPort:                %d\n", appConfig.tlsPort))
+	sb.WriteString(fmt.Sprintf("\ttlsCertFile:            %s\n", appConfig.tlsCertFile))
+	sb.WriteString(fmt.Sprintf("\ttlsKeyFile:             %s\n", appConfig.tlsKeyFile))
+	sb.WriteString(fmt.Sprintf("\ttlsSelfSigned:          %v\n", appConfig.tlsSelfSigned))
+	sb.WriteString(fmt.Sprintf("\ttlsSANs:                %s\n", strings.Join(appConfig.tlsSANs, ", ")))
+	sb.WriteString(fmt.Sprintf("\ttlsClientAuth:          %s\n", appConfig.tlsClientAuth))
+	sb.WriteString(fmt.Sprintf("\ttlsClientCAFile:        %s\n", appConfig.tlsClientCAFile))
+	sb.WriteString(fmt.Sprintf("\trequestCAFile:          %s\n", appConfig.requestCAFile))
+	sb.WriteString(fmt.Sprintf("\th2cEnabled:             %v\n", appConfig.h2cEnabled))
+	sb.WriteString(fmt.Sprintf("\thttp3Enabled:           %v\n", appConfig.http3Enabled))
+	sb.WriteString(fmt.Sprintf("\tgrpcPort:               %d\n", appConfig.grpcPort))
+	sb.WriteString(fmt.Sprintf("\tsocketListeners:        %s\n", strings.Join(appConfig.socketListenerNames(), ", ")))
+	sb.WriteString(fmt.Sprintf("\tproxyProtocol:          %v\n", appConfig.proxyProtocol))
+	sb.WriteString(fmt.Sprintf("\ttrustedProxies:         %s\n", strings.Join(appConfig.trustedProxyNames(), ", ")))
 	sb.WriteString(fmt.Sprintf("\tready:                  %v\n", appConfig.ready))
 	sb.WriteString(fmt.Sprintf("\talive:                  %v\n", appConfig.alive))
+	sb.WriteString(fmt.Sprintf("\tstarted:                %v\n", appConfig.started))
 	sb.WriteString(fmt.Sprintf("\t/ enabled:              %v\n", appConfig.rootEnabled))
 	sb.WriteString(fmt.Sprintf("\t/ delay seconds:        %d\n", appConfig.rootDelaySeconds))
 	sb.WriteString(fmt.Sprintf("\tstartup delay seconds:  %d\n", appConfig.startUpDelaySeconds))
 	sb.WriteString(fmt.Sprintf("\tteardown delay seconds: %d\n", appConfig.tearDownDelaySeconds))
-	sb.WriteString(fmt.Sprintf("\tApplication name:       %s\n", appConfig.applicationName))
-	sb.WriteString(fmt.Sprintf("\tApplciation version:    %s\n", appConfig.applicationVersion))
-	sb.WriteString(fmt.Sprintf("\tApplication message:    %s\n", appConfig.applicationMessage))
-	sb.WriteString(fmt.Sprintf("\tcolor:                  %s\n", appConfig.color))
+	sb.WriteString(fmt.Sprintf("\tdrain timeout seconds:  %d\n", appConfig.drainTimeoutSeconds))
+	sb.WriteString(fmt.Sprintf("\tApplication name:       %s\n", appConfig.redact("name", appConfig.applicationName)))
+	sb.WriteString(fmt.Sprintf("\tApplciation version:    %s\n", appConfig.redact("version", appConfig.applicationVersion)))
+	sb.WriteString(fmt.Sprintf("\tApplication message:    %s\n", appConfig.redact("message", appConfig.applicationMessage)))
+	sb.WriteString(fmt.Sprintf("\tcolor:                  %s\n", appConfig.redact("color", appConfig.color)))
 	sb.WriteString(fmt.Sprintf("\tlogToFileOnly:          %v\n", appConfig.logToFileOnly))
+	sb.WriteString(fmt.Sprintf("\tlogFormat:              %s\n", appConfig.logFormat))
+	sb.WriteString(fmt.Sprintf("\tlogLevel:               %s\n", appConfig.logLevel))
+	sb.WriteString(fmt.Sprintf("\taccessLog:              %s\n", appConfig.accessLogFormat))
+	sb.WriteString(fmt.Sprintf("\taccessLogExcludedPaths: %s\n", strings.Join(appConfig.accessLogExcludedPaths, ", ")))
+	sb.WriteString(fmt.Sprintf("\tnetworkModes:           %s\n", strings.Join(appConfig.networkModeNames(), ", ")))
+	sb.WriteString(fmt.Sprintf("\techoMaxBodyBytes:       %d\n", appConfig.echoMaxBodyBytes))
+	sb.WriteString(fmt.Sprintf("\ttoolboxEnabled:         %v\n", appConfig.toolboxEnabled))
+	sb.WriteString(fmt.Sprintf("\ttemplatePath:           %s\n", appConfig.templatePath))
 	sb.WriteString(fmt.Sprintf("\tpersistMetaInfo:        %v\n", appConfig.persistMetaInfo))
 	sb.WriteString(fmt.Sprintf("\tcatImageUrl:            %s\n", appConfig.catImageUrl))
+	sb.WriteString(fmt.Sprintf("\trevealSecrets:          %v\n", appConfig.revealSecrets))
+	sb.WriteString("\tsecrets:\n")
+	for _, secret := range appConfig.readSecrets() {
+		if secret.Error != "" {
+			sb.WriteString(fmt.Sprintf("\t\t%s (%s): %s\n", secret.Name, secret.Path, secret.Error))
+		} else {
+			sb.WriteString(fmt.Sprintf("\t\t%s (%s): %s\n", secret.Name, secret.Path, secret.Value))
+		}
+	}
 	return sb.String()
 }
 
@@ -64,6 +154,8 @@ func newAppConfig(configFilePath string) *appConfig {
 		rootDelaySeconds:     0,
 		startUpDelaySeconds:  0,
 		tearDownDelaySeconds: 0,
+		drainTimeoutSeconds:  30,
+		secretProperties:     map[string]bool{},
 	}
 
 	return ret
@@ -74,49 +166,165 @@ func (appConfig *appConfig) initAppConfig(isReady bool) {
 	appConfig.alive = true
 	appConfig.ready = isReady
 
-	fileConfig, err := properties.LoadFile(appConfig.configFilePath, properties.UTF8)
+	fileConfig, configFiles, err := loadFileConfig(appConfig.configFilePath)
 	if err != nil {
-		log.Errorf("configuration file %s not found: 	%v", appConfig.configFilePath, err)
-	}
-
-	appConfig.applicationPort = getAppConfigIntValue(fileConfig, "port", "", 8080)
-	appConfig.applicationName = getAppConfigStringValue(fileConfig, "name", "APP_NAME", "not set")
-	appConfig.applicationVersion = getAppConfigStringValue(fileConfig, "version", "APP_VERSION", "not set")
-	appConfig.applicationMessage = getAppConfigStringValue(fileConfig, "message", "APP_MESSAGE", "not set")
-	appConfig.color = getAppConfigStringValue(fileConfig, "color", "APP_COLOR", "not set")
-	appConfig.logToFileOnly = getAppConfigBoolValue(fileConfig, "logToFileOnly", "", false)
-	appConfig.persistMetaInfo = getAppConfigBoolValue(fileConfig, "persistMetaInfo", "", false)
-	appConfig.startUpDelaySeconds = getAppConfigIntValue(fileConfig, "startUpDelaySeconds", "", 0)
-	appConfig.tearDownDelaySeconds = getAppConfigIntValue(fileConfig, "tearDownDelaySeconds", "", 0)
-	catMode := getAppConfigBoolValue(fileConfig, "catMode", "", false)
+		configLog.Errorf("configuration file %s not found: 	%v", appConfig.configFilePath, err)
+	}
+	appConfig.configFiles = configFiles
+
+	appConfig.loading = &configLoading{
+		secretProperties: map[string]bool{},
+		envOverrides:     map[string]*configChange{},
+	}
+	appConfig.applicationPort = appConfig.getAppConfigIntValue(fileConfig, "port", "", 8080)
+	appConfig.managementPort = appConfig.getAppConfigIntValue(fileConfig, "managementPort", "APP_MANAGEMENT_PORT", 0)
+	appConfig.tlsPort = appConfig.getAppConfigIntValue(fileConfig, "tlsPort", "APP_TLS_PORT", 8443)
+	appConfig.tlsCertFile = appConfig.getAppConfigStringValue(fileConfig, "tlsCertFile", "APP_TLS_CERT_FILE", "")
+	appConfig.tlsKeyFile = appConfig.getAppConfigStringValue(fileConfig, "tlsKeyFile", "APP_TLS_KEY_FILE", "")
+	appConfig.tlsSelfSigned = appConfig.getAppConfigBoolValue(fileConfig, "tlsSelfSigned", "APP_TLS_SELF_SIGNED", false)
+	appConfig.tlsSANs = splitList(appConfig.getAppConfigStringValue(fileConfig, "tlsSANs", "APP_TLS_SANS", ""))
+	appConfig.tlsClientAuth = appConfig.getAppConfigStringValue(fileConfig, "tlsClientAuth", "APP_TLS_CLIENT_AUTH", "none")
+	appConfig.tlsClientCAFile = appConfig.getAppConfigStringValue(fileConfig, "tlsClientCAFile", "APP_TLS_CLIENT_CA_FILE", "")
+	appConfig.requestCAFile = appConfig.getAppConfigStringValue(fileConfig, "requestCAFile", "APP_REQUEST_CA_FILE", "")
+	appConfig.h2cEnabled = appConfig.getAppConfigBoolValue(fileConfig, "h2cEnabled", "APP_H2C_ENABLED", false)
+	appConfig.http3Enabled = appConfig.getAppConfigBoolValue(fileConfig, "http3Enabled", "APP_HTTP3_ENABLED", false)
+	appConfig.grpcPort = appConfig.getAppConfigIntValue(fileConfig, "grpcPort", "APP_GRPC_PORT", 0)
+	appConfig.socketListeners = parseSocketListeners(appConfig.getAppConfigStringValue(fileConfig, "socketListeners", "APP_SOCKET_LISTENERS", ""))
+	appConfig.proxyProtocol = appConfig.getAppConfigBoolValue(fileConfig, "proxyProtocol", "APP_PROXY_PROTOCOL", false)
+	appConfig.trustedProxies = parseTrustedProxies(appConfig.getAppConfigStringValue(fileConfig, "trustedProxies", "APP_TRUSTED_PROXIES", ""))
+	appConfig.applicationName = appConfig.getAppConfigStringValue(fileConfig, "name", "APP_NAME", "not set")
+	appConfig.applicationVersion = appConfig.getAppConfigStringValue(fileConfig, "version", "APP_VERSION", "not set")
+	appConfig.applicationMessage = appConfig.getAppConfigStringValue(fileConfig, "message", "APP_MESSAGE", "not set")
+	appConfig.color = appConfig.getAppConfigStringValue(fileConfig, "color", "APP_COLOR", "not set")
+	appConfig.logToFileOnly = appConfig.getAppConfigBoolValue(fileConfig, "logToFileOnly", "", false)
+	appConfig.logFormat = appConfig.getAppConfigStringValue(fileConfig, "logFormat", "APP_LOG_FORMAT", "text")
+	appConfig.logLevel = appConfig.getAppConfigStringValue(fileConfig, "logLevel", "APP_LOG_LEVEL", "info")
+	appConfig.applyLogging()
+	appConfig.accessLogFormat = appConfig.getAppConfigStringValue(fileConfig, "accessLog", "APP_ACCESS_LOG", "off")
+	if err := validateAccessLogFormat(appConfig.accessLogFormat); err != nil {
+		configLog.Errorf("could not set access log format: %s", err)
+		appConfig.accessLogFormat = "off"
+	}
+	appConfig.accessLogExcludedPaths = splitList(appConfig.getAppConfigStringValue(fileConfig, "accessLogExcludedPaths", "", ""))
+	appConfig.networkModes = parseNetworkModes(appConfig.getAppConfigStringValue(fileConfig, "networkModes", "APP_NETWORK_MODES", ""))
+	appConfig.echoMaxBodyBytes = appConfig.getAppConfigIntValue(fileConfig, "echoMaxBodyBytes", "", 65536)
+	appConfig.toolboxEnabled = appConfig.getAppConfigBoolValue(fileConfig, "toolboxEnabled", "APP_TOOLBOX_ENABLED", false)
+	appConfig.templatePath = appConfig.getAppConfigStringValue(fileConfig, "templatePath", "APP_TEMPLATE_PATH", "")
+	appConfig.persistMetaInfo = appConfig.getAppConfigBoolValue(fileConfig, "persistMetaInfo", "", false)
+	appConfig.startUpDelaySeconds = appConfig.getAppConfigIntValue(fileConfig, "startUpDelaySeconds", "", 0)
+	appConfig.tearDownDelaySeconds = appConfig.getAppConfigIntValue(fileConfig, "tearDownDelaySeconds", "", 0)
+	appConfig.drainTimeoutSeconds = appConfig.getAppConfigIntValue(fileConfig, "drainTimeoutSeconds", "", 30)
+	appConfig.revealSecrets = appConfig.getAppConfigBoolValue(fileConfig, "revealSecrets", "APP_REVEAL_SECRETS", false)
+	appConfig.secretFiles = getSecretFiles(fileConfig)
+	appConfig.mapsMutex.Lock()
+	appConfig.secretProperties = appConfig.loading.secretProperties
+	appConfig.envOverrides = appConfig.loading.envOverrides
+	appConfig.mapsMutex.Unlock()
+	appConfig.loading = nil
+	appConfig.parseValueTemplates()
+	catMode := appConfig.getAppConfigBoolValue(fileConfig, "catMode", "", false)
 	if catMode {
 		appConfig.catImageUrl, err = getCat()
 		if err != nil {
-			log.Error("could not obtain cat image", err)
+			configLog.Error("could not obtain cat image", err)
+		}
+	}
+}
+
+// splitList splits a comma separated list, omitting empty items.
+func splitList(value string) []string {
+	items := []string{}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
 		}
 	}
+	return items
 }
 
-func getAppConfigStringValue(fileConfig *properties.Properties, fileConfigProperty, envVarName, defaultValue string) string {
+// loadFileConfig loads the configuration file or, if the path is a directory, all '*.conf' files in it in lexical
+// order, where later files override properties of earlier ones. Afterwards expressions of the form '${NAME}' and
+// '${NAME:-default}' get expanded with other properties or environment variables.
+func loadFileConfig(path string) (*properties.Properties, []string, error) {
+	fileNames := []string{path}
+	fileInfo, err := os.Stat(path)
+	if err == nil && fileInfo.IsDir() {
+		fileNames, err = filepath.Glob(filepath.Join(path, "*.conf"))
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(fileNames) == 0 {
+			return nil, nil, fmt.Errorf("no '*.conf' files found in directory '%s'", path)
+		}
+	}
+
+	loader := &properties.Loader{Encoding: properties.UTF8, DisableExpansion: true}
+	fileConfig, err := loader.LoadAll(fileNames)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, key := range fileConfig.Keys() {
+		value, _ := fileConfig.Get(key)
+		_, _, err = fileConfig.Set(key, expandValue(fileConfig, value, map[string]bool{key: true}))
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return fileConfig, fileNames, nil
+}
+
+func expandValue(fileConfig *properties.Properties, value string, expanding map[string]bool) string {
+	return expansionPattern.ReplaceAllStringFunc(value, func(expression string) string {
+		match := expansionPattern.FindStringSubmatch(expression)
+		name, defaultValue := match[1], match[2]
+		if propertyValue, ok := fileConfig.Get(name); ok && !expanding[name] {
+			expanding[name] = true
+			defer delete(expanding, name)
+			return expandValue(fileConfig, propertyValue, expanding)
+		}
+		if envVarValue := os.Getenv(name); envVarValue != "" {
+			return envVarValue
+		}
+		return defaultValue
+	})
+}
+
+// lookupEnvVar looks up the environment variable and remembers if it overrides the property from the config file.
+func (appConfig *appConfig) lookupEnvVar(fileConfig *properties.Properties, fileConfigProperty, envVarName string) (string, bool) {
+	envVarValue, envVarExists := os.LookupEnv(envVarName)
+	if envVarExists {
+		var fileConfigPropertyValue any
+		if fileConfig != nil {
+			if value, ok := fileConfig.Get(fileConfigProperty); ok {
+				fileConfigPropertyValue = value
+			}
+		}
+		appConfig.loading.envOverrides[fileConfigProperty] = &configChange{Old: fileConfigPropertyValue, New: envVarValue}
+	}
+	return envVarValue, envVarExists
+}
+
+func (appConfig *appConfig) getAppConfigStringValue(fileConfig *properties.Properties, fileConfigProperty, envVarName, defaultValue string) string {
 	if envVarName != "" {
-		envVarValue, envVarExists := os.LookupEnv(envVarName)
+		envVarValue, envVarExists := appConfig.lookupEnvVar(fileConfig, fileConfigProperty, envVarName)
 		if envVarExists {
-			return envVarValue
+			return appConfig.resolveFileReference(fileConfigProperty, envVarValue, defaultValue)
 		}
 	}
 	if fileConfig == nil {
 		return defaultValue
 	}
-	return fileConfig.GetString(fileConfigProperty, defaultValue)
+	return appConfig.resolveFileReference(fileConfigProperty, fileConfig.GetString(fileConfigProperty, defaultValue), defaultValue)
 }
 
-func getAppConfigBoolValue(fileConfig *properties.Properties, fileConfigProperty, envVarName string, defaultValue bool) bool {
+func (appConfig *appConfig) getAppConfigBoolValue(fileConfig *properties.Properties, fileConfigProperty, envVarName string, defaultValue bool) bool {
 	if envVarName != "" {
-		envVarValue, envVarExists := os.LookupEnv(envVarName)
+		envVarValue, envVarExists := appConfig.lookupEnvVar(fileConfig, fileConfigProperty, envVarName)
 		if envVarExists {
+			envVarValue = appConfig.resolveFileReference(fileConfigProperty, envVarValue, "")
 			value, err := strconv.ParseBool(envVarValue)
 			if err != nil {
-				log.Errorf("could not convert envirnment variable named '%s' with value '%s' to bool:", envVarName, envVarValue)
+				configLog.Errorf("could not convert envirnment variable named '%s' with value '%s' to bool:", envVarName, appConfig.loading.redact(fileConfigProperty, envVarValue))
 				return defaultValue
 			}
 			return value
@@ -125,25 +333,26 @@ func getAppConfigBoolValue(fileConfig *properties.Properties, fileConfigProperty
 	if fileConfig == nil {
 		return defaultValue
 	}
-	fileConfigPropertyValue := fileConfig.GetString(fileConfigProperty, "")
+	fileConfigPropertyValue := appConfig.resolveFileReference(fileConfigProperty, fileConfig.GetString(fileConfigProperty, ""), "")
 	if fileConfigPropertyValue == "" {
 		return defaultValue
 	}
 	value, err := strconv.ParseBool(fileConfigPropertyValue)
 	if err != nil {
-		log.Errorf("could not convert file configuration property named '%s' with value '%s' to bool:", fileConfigProperty, fileConfigPropertyValue)
+		configLog.Errorf("could not convert file configuration property named '%s' with value '%s' to bool:", fileConfigProperty, appConfig.loading.redact(fileConfigProperty, fileConfigPropertyValue))
 		return defaultValue
 	}
 	return value
 }
 
-func getAppConfigIntValue(fileConfig *properties.Properties, fileConfigProperty, envVarName string, defaultValue int) int {
+func (appConfig *appConfig) getAppConfigIntValue(fileConfig *properties.Properties, fileConfigProperty, envVarName string, defaultValue int) int {
 	if envVarName != "" {
-		envVarValue, envVarExists := os.LookupEnv(envVarName)
+		envVarValue, envVarExists := appConfig.lookupEnvVar(fileConfig, fileConfigProperty, envVarName)
 		if envVarExists {
+			envVarValue = appConfig.resolveFileReference(fileConfigProperty, envVarValue, "")
 			value, err := strconv.Atoi(envVarValue)
 			if err != nil {
-				log.Errorf("could not convert envirnment variable named '%s' with value '%s' to int:", envVarName, envVarValue)
+				configLog.Errorf("could not convert envirnment variable named '%s' with value '%s' to int:", envVarName, appConfig.loading.redact(fileConfigProperty, envVarValue))
 				return defaultValue
 			}
 			return value
@@ -152,13 +361,13 @@ func getAppConfigIntValue(fileConfig *properties.Properties, fileConfigProperty,
 	if fileConfig == nil {
 		return defaultValue
 	}
-	fileConfigPropertyValue := fileConfig.GetString(fileConfigProperty, "")
+	fileConfigPropertyValue := appConfig.resolveFileReference(fileConfigProperty, fileConfig.GetString(fileConfigProperty, ""), "")
 	if fileConfigPropertyValue == "" {
 		return defaultValue
 	}
 	value, err := strconv.Atoi(fileConfigPropertyValue)
 	if err != nil {
-		log.Errorf("could not convert file configuration property named '%s' with value '%s' to int:", fileConfigProperty, fileConfigPropertyValue)
+		configLog.Errorf("could not convert file configuration property named '%s' with value '%s' to int:", fileConfigProperty, appConfig.loading.redact(fileConfigProperty, fileConfigPropertyValue))
 		return defaultValue
 	}
 	return value
@@ -176,17 +385,17 @@ func getCat() (string, error) {
 	}
 	defer func() {
 		if err := resp.Body.Close(); err != nil {
-			log.Errorf("error on closing: %v", err)
+			configLog.Errorf("error on closing: %v", err)
 		}
 	}()
 
 	bodyBytes, err := io.ReadAll(resp.Body)
 	if err != nil {
-		log.Errorf("error on reading the response body: '%s'", err)
+		configLog.Errorf("error on reading the response body: '%s'", err)
 		return "", err
 	}
 	bodyString := string(bodyBytes)
-	log.Infof("Got response from cat api: %s", bodyString)
+	configLog.Infof("Got response from cat api: %s", bodyString)
 
 	var cats []catStruct
 	err = json.Unmarshal(bodyBytes, &cats)
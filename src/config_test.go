@@ -0,0 +1,57 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/magiconair/properties"
+)
+
+func TestExpandValue(t *testing.T) {
+	t.Setenv("TRAINING_ENV", "from-env")
+	fileConfig := properties.NewProperties()
+	fileConfig.DisableExpansion = true
+	for key, value := range map[string]string{
+		"name":         "app",
+		"nested":       "${name}-nested",
+		"TRAINING_ENV": "from-property",
+		"self":         "${self}",
+		"ping":         "${pong}",
+		"pong":         "${ping}",
+	} {
+		if _, _, err := fileConfig.Set(key, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{"plain value", "plain", "plain"},
+		{"property", "${name}", "app"},
+		{"property before environment variable", "${TRAINING_ENV}", "from-property"},
+		{"nested property", "${nested}", "app-nested"},
+		{"default", "${missing:-fallback}", "fallback"},
+		{"empty default", "${missing:-}", ""},
+		{"missing without default", "${missing}", ""},
+		{"several expressions", "${name}:${missing:-8080}", "app:8080"},
+		{"self reference", "${self}", ""},
+		{"cyclic reference", "${ping}", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := expandValue(fileConfig, test.value, map[string]bool{}); got != test.want {
+				t.Errorf("expandValue(%q) = %q, want %q", test.value, got, test.want)
+			}
+		})
+	}
+}
+
+func TestExpandValueEnvironmentVariable(t *testing.T) {
+	t.Setenv("TRAINING_ONLY_ENV", "from-env")
+	fileConfig := properties.NewProperties()
+	if got := expandValue(fileConfig, "${TRAINING_ONLY_ENV:-default}", map[string]bool{}); got != "from-env" {
+		t.Errorf("expandValue() = %q, want %q", got, "from-env")
+	}
+}
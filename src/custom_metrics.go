@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var metricNamePattern = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+
+// customMetrics are arbitrary gauges published on '/metrics', eg for autoscaling on custom metrics.
+type customMetrics struct {
+	mutex    sync.Mutex
+	registry *prometheus.Registry
+	gauges   map[string]*customGauge
+}
+
+type customGauge struct {
+	gauge     prometheus.Gauge
+	value     float64
+	stopRamp  chan struct{}
+	rampUntil time.Time
+}
+
+type customMetricInfo struct {
+	Name      string  `json:"name"`
+	Value     float64 `json:"value"`
+	RampUntil string  `json:"rampUntil,omitempty"`
+}
+
+func newCustomMetrics(registry *prometheus.Registry) *customMetrics {
+	return &customMetrics{
+		registry: registry,
+		gauges:   map[string]*customGauge{},
+	}
+}
+
+// getOrRegister returns the gauge with the given name, it has to be called with the mutex held.
+func (c *customMetrics) getOrRegister(name string) (*customGauge, error) {
+	if gauge, exists := c.gauges[name]; exists {
+		return gauge, nil
+	}
+	if !metricNamePattern.MatchString(name) {
+		return nil, fmt.Errorf("invalid metric name '%s'", name)
+	}
+	gauge := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: name,
+		Help: "Custom metric set via 'set metric' or 'ramp metric'.",
+	})
+	if err := c.registry.Register(gauge); err != nil {
+		return nil, fmt.Errorf("cannot register metric '%s': %s", name, err)
+	}
+	c.gauges[name] = &customGauge{gauge: gauge}
+	return c.gauges[name], nil
+}
+
+// set sets the value of the metric and stops a running ramp of it.
+func (c *customMetrics) set(name string, value float64) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	gauge, err := c.getOrRegister(name)
+	if err != nil {
+		return err
+	}
+	gauge.stop()
+	gauge.set(value)
+	return nil
+}
+
+// ramp changes the value of the metric linearly from 'from' to 'to' within the duration, updated every second.
+func (c *customMetrics) ramp(name string, from, to float64, duration time.Duration) error {
+	if duration <= 0 {
+		return fmt.Errorf("duration of the ramp has to be positive")
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	gauge, err := c.getOrRegister(name)
+	if err != nil {
+		return err
+	}
+	gauge.stop()
+	gauge.set(from)
+	stopRamp := make(chan struct{})
+	gauge.stopRamp = stopRamp
+	start := time.Now()
+	gauge.rampUntil = start.Add(duration)
+
+	go func() {
+		ticker := time.NewTicker(1 * time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stopRamp:
+				return
+			case now := <-ticker.C:
+				c.mutex.Lock()
+				select {
+				case <-stopRamp:
+					c.mutex.Unlock()
+					return
+				default:
+				}
+				elapsed := now.Sub(start)
+				if elapsed >= duration {
+					gauge.set(to)
+					gauge.stopRamp = nil
+					gauge.rampUntil = time.Time{}
+					c.mutex.Unlock()
+					metricsLog.Infof("Finished ramping metric '%s' to %v", name, to)
+					return
+				}
+				gauge.set(from + (to-from)*elapsed.Seconds()/duration.Seconds())
+				c.mutex.Unlock()
+			}
+		}
+	}()
+	return nil
+}
+
+func (c *customMetrics) list() []*customMetricInfo {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	infos := make([]*customMetricInfo, 0, len(c.gauges))
+	for name, gauge := range c.gauges {
+		info := &customMetricInfo{
+			Name:  name,
+			Value: gauge.value,
+		}
+		if !gauge.rampUntil.IsZero() {
+			info.RampUntil = gauge.rampUntil.Format(time.RFC3339)
+		}
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos
+}
+
+func (g *customGauge) set(value float64) {
+	g.value = value
+	g.gauge.Set(value)
+}
+
+func (g *customGauge) stop() {
+	if g.stopRamp != nil {
+		close(g.stopRamp)
+		g.stopRamp = nil
+		g.rampUntil = time.Time{}
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+)
+
+type cookieInfo struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// echoInfo is everything that arrived at the application, eg after being rewritten by proxies.
+type echoInfo struct {
+	*requestInfo
+	LocalAddr     string              `json:"localAddr"`
+	Query         map[string][]string `json:"query"`
+	Cookies       []*cookieInfo       `json:"cookies"`
+	ContentLength int64               `json:"contentLength"`
+	Body          string              `json:"body"`
+	BodyTruncated bool                `json:"bodyTruncated"`
+}
+
+func newEchoInfo(r *http.Request, maxBodyBytes int) (*echoInfo, error) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, int64(maxBodyBytes)+1))
+	if err != nil {
+		return nil, err
+	}
+	bodyTruncated := len(body) > maxBodyBytes
+	if bodyTruncated {
+		body = body[:maxBodyBytes]
+	}
+
+	localAddr := ""
+	if addr, ok := r.Context().Value(http.LocalAddrContextKey).(net.Addr); ok {
+		localAddr = addr.String()
+	}
+
+	cookies := []*cookieInfo{}
+	for _, cookie := range r.Cookies() {
+		cookies = append(cookies, &cookieInfo{
+			Name:  cookie.Name,
+			Value: cookie.Value,
+		})
+	}
+
+	return &echoInfo{
+		requestInfo:   newRequestInfo(r),
+		LocalAddr:     localAddr,
+		Query:         r.URL.Query(),
+		Cookies:       cookies,
+		ContentLength: r.ContentLength,
+		Body:          string(body),
+		BodyTruncated: bodyTruncated,
+	}, nil
+}
+
+func (ei *echoInfo) String() string {
+	var sb strings.Builder
+	sb.WriteString(ei.requestInfo.String())
+	sb.WriteString(fmt.Sprintf("\tLocalAddr:  %v\n", ei.LocalAddr))
+	sb.WriteString("\tQuery:\n")
+	for key, value := range ei.Query {
+		sb.WriteString(fmt.Sprintf("\t\t%v: %v\n", key, value))
+	}
+	sb.WriteString("\tCookies:\n")
+	for _, cookie := range ei.Cookies {
+		sb.WriteString(fmt.Sprintf("\t\t%v: %v\n", cookie.Name, cookie.Value))
+	}
+	if ei.TLS != nil {
+		sb.WriteString("\tTLS Details:\n")
+		sb.WriteString(fmt.Sprintf("\t\tVersion:      %s\n", ei.TLS.Version))
+		sb.WriteString(fmt.Sprintf("\t\tCipher Suite: %s\n", ei.TLS.CipherSuite))
+		sb.WriteString(fmt.Sprintf("\t\tServer Name:  %s\n", ei.TLS.ServerName))
+		sb.WriteString(fmt.Sprintf("\t\tProtocol:     %s\n", ei.TLS.NegotiatedProtocol))
+		sb.WriteString(fmt.Sprintf("\t\tVerified:     %v\n", ei.TLS.Verified))
+		for _, ci := range ei.TLS.CertInfos {
+			sb.WriteString(fmt.Sprintf("\t\tClient Certificate Subject: %s - Issuer: %s\n", ci.Subject, ci.Issuer))
+			sb.WriteString(fmt.Sprintf("\t\t\tSANs:       %s\n", strings.Join(ci.SANs, ", ")))
+			if ci.SpiffeId != "" {
+				sb.WriteString(fmt.Sprintf("\t\t\tSPIFFE ID:  %s\n", ci.SpiffeId))
+			}
+		}
+	}
+	sb.WriteString(fmt.Sprintf("\tBody (%d bytes", ei.ContentLength))
+	if ei.BodyTruncated {
+		sb.WriteString(", truncated")
+	}
+	sb.WriteString("):\n")
+	sb.WriteString(ei.Body)
+	if ei.Body != "" && !strings.HasSuffix(ei.Body, "\n") {
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+func (s *server) handleEcho(w http.ResponseWriter, r *http.Request) {
+	requestLog(r).Infof("Request to echo endpoint ('%s')", r.URL.Path)
+
+	w.Header().Set("Vary", "Accept")
+	format, err := negotiateFormat(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	echoInfo, err := newEchoInfo(r, s.config.echoMaxBodyBytes)
+	if err != nil {
+		requestLog(r).Errorf("error on reading the request body: %s", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if format == formatJSON {
+		writeJSON(w, http.StatusOK, echoInfo)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if _, err := fmt.Fprint(w, echoInfo); err != nil {
+		requestLog(r).Errorf("error on writing response for echo endpoint: %s", err)
+	}
+}
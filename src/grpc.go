@@ -0,0 +1,253 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+const infoServiceName = "training_application.Info"
+
+// infoServiceFile describes the Info service, which is built by hand instead of generated by protoc:
+//
+//	service Info {
+//	  rpc GetInfo(google.protobuf.Empty) returns (google.protobuf.Struct);
+//	}
+var infoServiceFile = &descriptorpb.FileDescriptorProto{
+	Name:       proto.String("training_application/info.proto"),
+	Package:    proto.String("training_application"),
+	Dependency: []string{"google/protobuf/empty.proto", "google/protobuf/struct.proto"},
+	Service: []*descriptorpb.ServiceDescriptorProto{{
+		Name: proto.String("Info"),
+		Method: []*descriptorpb.MethodDescriptorProto{{
+			Name:       proto.String("GetInfo"),
+			InputType:  proto.String(".google.protobuf.Empty"),
+			OutputType: proto.String(".google.protobuf.Struct"),
+		}},
+	}},
+	Syntax: proto.String("proto3"),
+}
+
+type infoServer interface {
+	getInfo(ctx context.Context) (*structpb.Struct, error)
+}
+
+var infoServiceDesc = grpc.ServiceDesc{
+	ServiceName: infoServiceName,
+	HandlerType: (*infoServer)(nil),
+	Methods: []grpc.MethodDesc{{
+		MethodName: "GetInfo",
+		Handler:    handleGetInfo,
+	}},
+	Metadata: infoServiceFile.GetName(),
+}
+
+// handleGetInfo follows the handlers generated by protoc, which call the method through the chained interceptors.
+func handleGetInfo(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+	in := &emptypb.Empty{}
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(infoServer).getInfo(ctx)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/" + infoServiceName + "/GetInfo",
+	}
+	handler := func(ctx context.Context, req any) (any, error) {
+		return srv.(infoServer).getInfo(ctx)
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// newGrpcServer serves 'grpc.health.v1.Health', the Info service and the server reflection, which allows
+// clients like grpcurl to discover the services.
+func (s *server) newGrpcServer() (*grpc.Server, error) {
+	fileDescriptor, err := protodesc.NewFile(infoServiceFile, protoregistry.GlobalFiles)
+	if err != nil {
+		return nil, err
+	}
+	if err := protoregistry.GlobalFiles.RegisterFile(fileDescriptor); err != nil {
+		return nil, err
+	}
+
+	grpcServer := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(s.trackInFlightUnary),
+		grpc.ChainStreamInterceptor(s.trackInFlightStream),
+	)
+	s.grpcHealthServer = &grpcHealthServer{config: s.config, shutdown: make(chan struct{})}
+	grpc_health_v1.RegisterHealthServer(grpcServer, s.grpcHealthServer)
+	grpcServer.RegisterService(&infoServiceDesc, &grpcInfoServer{server: s})
+	reflection.Register(grpcServer)
+	return grpcServer, nil
+}
+
+func (s *server) serveGrpc() {
+	listener, err := net.Listen("tcp", ":"+strconv.Itoa(s.config.grpcPort))
+	if err == nil {
+		err = s.grpcServer.Serve(listener)
+	}
+	if err != nil {
+		grpcLog.Errorf("error on starting the gRPC server on port %d: '%s'", s.config.grpcPort, err)
+		os.Exit(1)
+	}
+}
+
+// trackInFlightUnary counts unary calls as requests in flight, which are drained on shutdown.
+func (s *server) trackInFlightUnary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	s.inFlightRequests.Add(1)
+	defer s.inFlightRequests.Add(-1)
+	return handler(ctx, req)
+}
+
+func (s *server) trackInFlightStream(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	s.inFlightRequests.Add(1)
+	defer s.inFlightRequests.Add(-1)
+	return handler(srv, stream)
+}
+
+// shutdownGrpc waits for the running calls, including the streams of health watches, until the context is
+// done and closes them afterwards.
+func (s *server) shutdownGrpc(ctx context.Context) error {
+	stopped := make(chan struct{})
+	go func() {
+		s.grpcServer.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		s.grpcServer.Stop()
+		return ctx.Err()
+	}
+}
+
+// grpcHealthServer reports the same state as the probe endpoints: the empty service and 'readiness' report
+// 'ready', 'liveness' reports 'alive' and 'startup' reports 'started'.
+type grpcHealthServer struct {
+	grpc_health_v1.UnimplementedHealthServer
+	config   *appConfig
+	shutdown chan struct{}
+}
+
+// startShutdown ends the watches, which otherwise only end when the client cancels and would block the drain.
+func (h *grpcHealthServer) startShutdown() {
+	close(h.shutdown)
+}
+
+func (h *grpcHealthServer) status(service string) (grpc_health_v1.HealthCheckResponse_ServingStatus, bool) {
+	var serving bool
+	switch service {
+	case "", "readiness":
+		serving = h.config.ready
+	case "liveness":
+		serving = h.config.alive
+	case "startup":
+		serving = h.config.started
+	default:
+		return grpc_health_v1.HealthCheckResponse_SERVICE_UNKNOWN, false
+	}
+	if serving {
+		return grpc_health_v1.HealthCheckResponse_SERVING, true
+	}
+	return grpc_health_v1.HealthCheckResponse_NOT_SERVING, true
+}
+
+func (h *grpcHealthServer) Check(ctx context.Context, req *grpc_health_v1.HealthCheckRequest) (*grpc_health_v1.HealthCheckResponse, error) {
+	servingStatus, known := h.status(req.GetService())
+	if !known {
+		return nil, status.Errorf(codes.NotFound, "unknown service '%s'", req.GetService())
+	}
+	grpcLog.Infof("Health check of service '%s' responded with %s", req.GetService(), servingStatus)
+	return &grpc_health_v1.HealthCheckResponse{Status: servingStatus}, nil
+}
+
+// Watch sends the status whenever it changes, checking every second. On shutdown it sends 'NOT_SERVING' and ends.
+func (h *grpcHealthServer) Watch(req *grpc_health_v1.HealthCheckRequest, stream grpc_health_v1.Health_WatchServer) error {
+	grpcLog.Infof("Watching health of service '%s'", req.GetService())
+	ticker := time.NewTicker(1 * time.Second)
+	defer ticker.Stop()
+	lastStatus := grpc_health_v1.HealthCheckResponse_UNKNOWN
+	for {
+		servingStatus, _ := h.status(req.GetService())
+		if servingStatus != lastStatus {
+			if err := stream.Send(&grpc_health_v1.HealthCheckResponse{Status: servingStatus}); err != nil {
+				return err
+			}
+			lastStatus = servingStatus
+		}
+		select {
+		case <-ticker.C:
+		case <-stream.Context().Done():
+			return nil
+		case <-h.shutdown:
+			grpcLog.Infof("Ending the watch of service '%s' due to shutdown", req.GetService())
+			return stream.Send(&grpc_health_v1.HealthCheckResponse{Status: grpc_health_v1.HealthCheckResponse_NOT_SERVING})
+		}
+	}
+}
+
+type grpcInfoServer struct {
+	server *server
+}
+
+// getInfo responds with the data of the root endpoint, where the request info is built from the call.
+func (i *grpcInfoServer) getInfo(ctx context.Context) (*structpb.Struct, error) {
+	requestInfo := newGrpcRequestInfo(ctx, "/"+infoServiceName+"/GetInfo")
+	grpcLog.Info("Request to Info service")
+
+	data, err := json.Marshal(i.server.newTemplateData(requestInfo))
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return structpb.NewStruct(fields)
+}
+
+func newGrpcRequestInfo(ctx context.Context, fullMethod string) *requestInfo {
+	header := http.Header{}
+	md, _ := metadata.FromIncomingContext(ctx)
+	for key, values := range md {
+		header[http.CanonicalHeaderKey(key)] = values
+	}
+	remoteAddr := ""
+	if p, ok := peer.FromContext(ctx); ok {
+		remoteAddr = p.Addr.String()
+	}
+	host := ""
+	if authority := md.Get(":authority"); len(authority) > 0 {
+		host = authority[0]
+	}
+	return &requestInfo{
+		Method:     http.MethodPost,
+		Url:        fullMethod,
+		Proto:      "HTTP/2.0",
+		Host:       host,
+		RemoteAddr: remoteAddr,
+		RequestUri: fullMethod,
+		Header:     header,
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+type inFlightInfoServer struct {
+	server   *server
+	inFlight int64
+}
+
+func (i *inFlightInfoServer) getInfo(ctx context.Context) (*structpb.Struct, error) {
+	i.inFlight = i.server.inFlightRequests.Load()
+	return &structpb.Struct{}, nil
+}
+
+func TestGetInfoCountsInFlight(t *testing.T) {
+	s := &server{}
+	grpcServer := grpc.NewServer(grpc.ChainUnaryInterceptor(s.trackInFlightUnary))
+	infoServer := &inFlightInfoServer{server: s}
+	grpcServer.RegisterService(&infoServiceDesc, infoServer)
+	listener := bufconn.Listen(1024 * 1024)
+	go func() { _ = grpcServer.Serve(listener) }()
+	defer grpcServer.Stop()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = conn.Close() }()
+
+	if err := conn.Invoke(context.Background(), "/"+infoServiceName+"/GetInfo", &emptypb.Empty{}, &structpb.Struct{}); err != nil {
+		t.Fatal(err)
+	}
+	if infoServer.inFlight != 1 {
+		t.Errorf("requests in flight during GetInfo = %d, want 1", infoServer.inFlight)
+	}
+	if got := s.inFlightRequests.Load(); got != 0 {
+		t.Errorf("requests in flight after GetInfo = %d, want 0", got)
+	}
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var configHistoryFilePath = dirPath + "config-history.jsonl"
+
+// history is nil if the application does not persist meta info.
+var history *configHistory
+
+type configHistoryRecord struct {
+	Version   int                      `json:"version"`
+	Timestamp string                   `json:"timestamp"`
+	Source    string                   `json:"source"`
+	Changes   map[string]*configChange `json:"changes"`
+}
+
+type configHistory struct {
+	mutex       sync.Mutex
+	lastVersion int
+}
+
+func newConfigHistory() (*configHistory, error) {
+	if _, err := os.Stat(dirPath); os.IsNotExist(err) {
+		return nil, err
+	}
+	records, err := readConfigHistory()
+	if err != nil {
+		return nil, err
+	}
+	lastVersion := 0
+	if len(records) > 0 {
+		lastVersion = records[len(records)-1].Version
+	}
+	return &configHistory{
+		lastVersion: lastVersion,
+	}, nil
+}
+
+// record appends the changes as new version to the history file, empty changes are skipped.
+func (h *configHistory) record(source string, changes map[string]*configChange) {
+	if h == nil || len(changes) == 0 {
+		return
+	}
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	record := &configHistoryRecord{
+		Version:   h.lastVersion + 1,
+		Timestamp: time.Now().Format(time.RFC3339),
+		Source:    source,
+		Changes:   changes,
+	}
+	line, err := json.Marshal(record)
+	if err != nil {
+		configLog.Errorf("cannot marshal config history record: %v", err)
+		return
+	}
+
+	historyFile, err := os.OpenFile(configHistoryFilePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		configLog.Errorf("cannot open file %s: %v", configHistoryFilePath, err)
+		return
+	}
+	defer historyFile.Close()
+
+	if _, err = fmt.Fprintf(historyFile, "%s\n", line); err != nil {
+		configLog.Errorf("cannot append to file %s: %v", configHistoryFilePath, err)
+		return
+	}
+	h.lastVersion = record.Version
+	configLog.Infof("Recorded config change version %d from source '%s'", record.Version, source)
+}
+
+func (h *configHistory) recordDiff(source string, before, after map[string]any) {
+	h.record(source, diffSnapshots(before, after))
+}
+
+// recordEnvOverrides records the properties which were overridden by environment variables
+// on the last initialization of the application configuration.
+func (h *configHistory) recordEnvOverrides(appConfig *appConfig) {
+	changes := map[string]*configChange{}
+	appConfig.mapsMutex.RLock()
+	for property, change := range appConfig.envOverrides {
+		if appConfig.secretProperties[property] {
+			change = &configChange{Old: change.Old, New: redactedValue}
+		}
+		changes[property] = change
+	}
+	appConfig.mapsMutex.RUnlock()
+	h.record("env", changes)
+}
+
+func readConfigHistory() ([]*configHistoryRecord, error) {
+	historyFile, err := os.Open(configHistoryFilePath)
+	if os.IsNotExist(err) {
+		return []*configHistoryRecord{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer historyFile.Close()
+
+	records := []*configHistoryRecord{}
+	scanner := bufio.NewScanner(historyFile)
+	for scanner.Scan() {
+		record := &configHistoryRecord{}
+		if err := json.Unmarshal(scanner.Bytes(), record); err != nil {
+			return nil, fmt.Errorf("cannot parse config history record '%s': %w", scanner.Text(), err)
+		}
+		records = append(records, record)
+	}
+	return records, scanner.Err()
+}
+
+// snapshot captures the effective configuration for the history. Secrets are always redacted,
+// regardless of the reveal mode, as the history gets persisted.
+func (appConfig *appConfig) snapshot() map[string]any {
+	secret := func(property, value string) any {
+		if appConfig.isSecret(property) {
+			return redactedValue
+		}
+		return value
+	}
+	return map[string]any{
+		"configFiles":            strings.Join(appConfig.configFiles, ","),
+		"port":                   appConfig.applicationPort,
+		"managementPort":         appConfig.managementPort,
+		"tlsPort":                appConfig.tlsPort,
+		"tlsCertFile":            appConfig.tlsCertFile,
+		"tlsKeyFile":             appConfig.tlsKeyFile,
+		"tlsSelfSigned":          appConfig.tlsSelfSigned,
+		"tlsSANs":                strings.Join(appConfig.tlsSANs, ","),
+		"tlsClientAuth":          appConfig.tlsClientAuth,
+		"tlsClientCAFile":        appConfig.tlsClientCAFile,
+		"requestCAFile":          appConfig.requestCAFile,
+		"h2cEnabled":             appConfig.h2cEnabled,
+		"http3Enabled":           appConfig.http3Enabled,
+		"grpcPort":               appConfig.grpcPort,
+		"socketListeners":        strings.Join(appConfig.socketListenerNames(), ","),
+		"proxyProtocol":          appConfig.proxyProtocol,
+		"trustedProxies":         strings.Join(appConfig.trustedProxyNames(), ","),
+		"ready":                  appConfig.ready,
+		"alive":                  appConfig.alive,
+		"rootEnabled":            appConfig.rootEnabled,
+		"rootDelaySeconds":       appConfig.rootDelaySeconds,
+		"startUpDelaySeconds":    appConfig.startUpDelaySeconds,
+		"tearDownDelaySeconds":   appConfig.tearDownDelaySeconds,
+		"drainTimeoutSeconds":    appConfig.drainTimeoutSeconds,
+		"name":                   secret("name", appConfig.applicationName),
+		"version":                secret("version", appConfig.applicationVersion),
+		"message":                secret("message", appConfig.applicationMessage),
+		"color":                  secret("color", appConfig.color),
+		"logToFileOnly":          appConfig.logToFileOnly,
+		"logFormat":              appConfig.logFormat,
+		"logLevel":               appConfig.logLevel,
+		"accessLog":              appConfig.accessLogFormat,
+		"accessLogExcludedPaths": strings.Join(appConfig.accessLogExcludedPaths, ","),
+		"networkModes":           strings.Join(appConfig.networkModeNames(), ","),
+		"echoMaxBodyBytes":       appConfig.echoMaxBodyBytes,
+		"toolboxEnabled":         appConfig.toolboxEnabled,
+		"templatePath":           appConfig.templatePath,
+		"persistMetaInfo":        appConfig.persistMetaInfo,
+		"catImageUrl":            appConfig.catImageUrl,
+		"revealSecrets":          appConfig.revealSecrets,
+	}
+}
+
+func diffSnapshots(before, after map[string]any) map[string]*configChange {
+	changes := map[string]*configChange{}
+	for property, value := range after {
+		if before[property] != value {
+			changes[property] = &configChange{Old: before[property], New: value}
+		}
+	}
+	return changes
+}
+
+func configHistoryString(records []*configHistoryRecord) string {
+	var sb strings.Builder
+	sb.WriteString("Config History:\n")
+	for _, record := range records {
+		sb.WriteString(fmt.Sprintf("\tVersion %d at %s from '%s':\n", record.Version, record.Timestamp, record.Source))
+		properties := make([]string, 0, len(record.Changes))
+		for property := range record.Changes {
+			properties = append(properties, property)
+		}
+		sort.Strings(properties)
+		for _, property := range properties {
+			change := record.Changes[property]
+			sb.WriteString(fmt.Sprintf("\t\t%s: %v => %v\n", property, change.Old, change.New))
+		}
+	}
+	return sb.String()
+}
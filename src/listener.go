@@ -0,0 +1,80 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+// appListener is the listener of the application server. Closing it makes new connections to the port fail
+// with 'connection refused', while established connections are still served.
+type appListener struct {
+	mutex    sync.Mutex
+	listener net.Listener
+	port     int
+}
+
+// listening returns whether the application server is listening and the port it is or was listening on.
+func (s *server) listening() (bool, int) {
+	s.appListener.mutex.Lock()
+	defer s.appListener.mutex.Unlock()
+	return s.appListener.listener != nil, s.appListener.port
+}
+
+// startListener opens the listener on the port and serves the application server on it.
+func (s *server) startListener(port int) error {
+	s.appListener.mutex.Lock()
+	defer s.appListener.mutex.Unlock()
+	if s.appListener.listener != nil {
+		return fmt.Errorf("already listening on port %d", s.appListener.port)
+	}
+	listener, err := s.listen(":" + strconv.Itoa(port))
+	if err != nil {
+		return err
+	}
+	s.appListener.listener = listener
+	s.appListener.port = port
+	go s.serveListener(listener)
+	return nil
+}
+
+func (s *server) stopListener() error {
+	s.appListener.mutex.Lock()
+	defer s.appListener.mutex.Unlock()
+	if s.appListener.listener == nil {
+		return fmt.Errorf("not listening")
+	}
+	err := s.appListener.listener.Close()
+	s.appListener.listener = nil
+	return err
+}
+
+// rebindListener moves the application server to another port. The new listener is opened before the current
+// one is closed, so the application keeps listening on the current port if the new one is not available.
+func (s *server) rebindListener(port int) error {
+	s.appListener.mutex.Lock()
+	defer s.appListener.mutex.Unlock()
+	listener, err := s.listen(":" + strconv.Itoa(port))
+	if err != nil {
+		return err
+	}
+	if s.appListener.listener != nil {
+		if err := s.appListener.listener.Close(); err != nil {
+			serverLog.Errorf("error on closing: %v", err)
+		}
+	}
+	s.appListener.listener = listener
+	s.appListener.port = port
+	go s.serveListener(listener)
+	return nil
+}
+
+func (s *server) serveListener(listener net.Listener) {
+	err := s.httpServer.Serve(listener)
+	if err != nil && !errors.Is(err, http.ErrServerClosed) && !errors.Is(err, net.ErrClosed) {
+		serverLog.Errorf("error on serving on '%s': '%s'", listener.Addr(), err)
+	}
+}
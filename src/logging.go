@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const requestIdHeader = "X-Request-Id"
+
+type contextKey string
+
+const requestIdContextKey contextKey = "requestId"
+
+var (
+	lifecycleLog = log.WithField("component", "lifecycle")
+	configLog    = log.WithField("component", "config")
+	serverLog    = log.WithField("component", "server")
+	cliLog       = log.WithField("component", "cli")
+	metricsLog   = log.WithField("component", "metrics")
+	persisterLog = log.WithField("component", "persister")
+	grpcLog      = log.WithField("component", "grpc")
+	socketLog    = log.WithField("component", "socket")
+)
+
+// podMetadataHook adds the pod metadata to every log line.
+type podMetadataHook struct {
+	fields log.Fields
+}
+
+func newPodMetadataHook() *podMetadataHook {
+	metadata := newPodMetadata()
+	fields := log.Fields{}
+	if metadata.Hostname != "" {
+		fields["hostname"] = metadata.Hostname
+	}
+	if metadata.PodName != "" {
+		fields["pod"] = metadata.PodName
+	}
+	if metadata.Namespace != "" {
+		fields["namespace"] = metadata.Namespace
+	}
+	return &podMetadataHook{
+		fields: fields,
+	}
+}
+
+func (h *podMetadataHook) Levels() []log.Level {
+	return log.AllLevels
+}
+
+func (h *podMetadataHook) Fire(entry *log.Entry) error {
+	for key, value := range h.fields {
+		if _, exists := entry.Data[key]; !exists {
+			entry.Data[key] = value
+		}
+	}
+	return nil
+}
+
+func newLogFormatter(format string) (log.Formatter, error) {
+	switch format {
+	case "text":
+		return &log.TextFormatter{FullTimestamp: true}, nil
+	case "logfmt":
+		return &log.TextFormatter{FullTimestamp: true, DisableColors: true}, nil
+	case "json":
+		return &log.JSONFormatter{}, nil
+	default:
+		return nil, fmt.Errorf("unknown log format '%s', use one of 'text', 'logfmt' or 'json'", format)
+	}
+}
+
+func setLogFormat(format string) error {
+	formatter, err := newLogFormatter(format)
+	if err != nil {
+		return err
+	}
+	log.SetFormatter(formatter)
+	return nil
+}
+
+func setLogLevel(level string) error {
+	logLevel, err := log.ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	log.SetLevel(logLevel)
+	return nil
+}
+
+// applyLogging applies the configured log format and level, invalid values fall back to 'text' and 'info'.
+func (appConfig *appConfig) applyLogging() {
+	if err := setLogFormat(appConfig.logFormat); err != nil {
+		configLog.Errorf("could not set log format: %s", err)
+		appConfig.logFormat = "text"
+		_ = setLogFormat(appConfig.logFormat)
+	}
+	if err := setLogLevel(appConfig.logLevel); err != nil {
+		configLog.Errorf("could not set log level: %s", err)
+		appConfig.logLevel = "info"
+		_ = setLogLevel(appConfig.logLevel)
+	}
+}
+
+// withRequestId passes the request id of the 'X-Request-Id' header on or generates a new one,
+// the id is added to the response header and to the logs of the request.
+func withRequestId(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestId := r.Header.Get(requestIdHeader)
+		if requestId == "" {
+			requestId = newRequestId()
+		}
+		w.Header().Set(requestIdHeader, requestId)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIdContextKey, requestId)))
+	})
+}
+
+func newRequestId() string {
+	bytes := make([]byte, 8)
+	_, _ = rand.Read(bytes)
+	return hex.EncodeToString(bytes)
+}
+
+func requestLog(r *http.Request) *log.Entry {
+	requestId, _ := r.Context().Value(requestIdContextKey).(string)
+	return serverLog.WithField("request_id", requestId)
+}
@@ -11,66 +11,86 @@ import (
 
 var config *appConfig
 var configFilePath string
+var startTime = time.Now()
 
 func init() {
 	log.SetFormatter(&log.TextFormatter{
 		FullTimestamp: true,
 	})
+	log.AddHook(newPodMetadataHook())
 }
 
 func main() {
 
 	configFilePath = getConfigFilePath()
 
-	log.Info("Initializing the application configuration")
+	lifecycleLog.Info("Initializing the application configuration")
 	config = newAppConfig(configFilePath)
 	config.initAppConfig(false)
-	log.Info(config)
+	lifecycleLog.Info(config)
+
+	if config.persistMetaInfo {
+		var err error
+		history, err = newConfigHistory()
+		if err != nil {
+			lifecycleLog.Errorf("error on starting the config history %v", err)
+		}
+		history.recordDiff("startup", nil, config.snapshot())
+		history.recordEnvOverrides(config)
+	}
 
 	if config.logToFileOnly {
-		log.Warn("Switching to log file only mode, subsequent logs will happen in the file 'application.log'")
+		lifecycleLog.Warn("Switching to log file only mode, subsequent logs will happen in the file 'application.log'")
 		file, err := os.OpenFile("application.log", os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
 		if err != nil {
 			panic(err)
 		}
 		defer func() {
 			if err := file.Close(); err != nil {
-				log.Errorf("error on closing: %v", err)
+				lifecycleLog.Errorf("error on closing: %v", err)
 			}
 		}()
 
 		log.SetOutput(file)
 	}
 
-	log.Info("Application is starting up")
+	server := newServer(config)
+	// signals are handled before listening, so a SIGTERM during the startup delay shuts down gracefully as well
+	signalChanel := make(chan os.Signal, 1)
+	signal.Notify(signalChanel, syscall.SIGTERM, syscall.SIGINT, syscall.SIGHUP)
+	go handleLifecycle(server, signalChanel)
+	go server.run()
+
+	lifecycleLog.Info("Application is starting up")
 	for i := 0; i < config.startUpDelaySeconds; i++ {
 		time.Sleep(1 * time.Second)
-		log.Infof("Starting the application took %d seconds of %d seconds", i+1, config.startUpDelaySeconds)
+		config.startUpProgressSeconds = i + 1
+		lifecycleLog.Infof("Starting the application took %d seconds of %d seconds", i+1, config.startUpDelaySeconds)
 	}
+	config.started = true
+	lifecycleLog.Info("Application has started")
 
-	cli := newCli(config)
-
+	cli := newCli(config, server)
 	go cli.handleStdin()
-	go handleLifecycle()
-
-	server := newServer(config)
 
 	if !config.persistMetaInfo {
-		log.Info("Application does not persist meta info")
+		lifecycleLog.Info("Application does not persist meta info")
 	} else {
 		persister, err := newPersister(config)
 		if err != nil {
-			log.Errorf("error on starting persistence %v", err)
+			lifecycleLog.Errorf("error on starting persistence %v", err)
 		} else {
 			go persister.writeMetaInfo()
 		}
 	}
 
-	config.ready = true
-	log.Info("Application set to ready")
-	log.Info("For getting help, type 'help'")
+	if !server.shuttingDown.Load() {
+		config.ready = true
+		lifecycleLog.Info("Application set to ready")
+	}
+	lifecycleLog.Info("For getting help, type 'help'")
 
-	server.run()
+	select {}
 }
 
 func getConfigFilePath() string {
@@ -78,35 +98,23 @@ func getConfigFilePath() string {
 	if len(args) == 2 && args[0] == "--configFilePath" {
 		return args[1]
 	}
-	log.Info("Config File Path not set, defaulting to './training-application.conf'")
+	lifecycleLog.Info("Config File Path not set, defaulting to './training-application.conf'")
 	return "./training-application.conf"
 }
 
-func handleLifecycle() {
-
-	signalChanel := make(chan os.Signal, 1)
-	signal.Notify(signalChanel, syscall.SIGTERM, syscall.SIGINT)
-	exitChanel := make(chan int)
-
-	go func(signalChanel chan os.Signal, exitChanel chan int) {
-		signal := <-signalChanel
-		if signal == syscall.SIGTERM || signal == syscall.SIGINT {
-			log.Infof("Got signal '%s'", signal)
-			config.ready = false
-			log.Info("Application set to not ready")
-			log.Info("Starting Graceful Shutdown")
-			for i := 0; i < config.tearDownDelaySeconds; i++ {
-				time.Sleep(1 * time.Second)
-				log.Infof("Graceful shutdown took %d seconds of %d seconds", i+1, config.tearDownDelaySeconds)
-			}
-			log.Info("Graceful Shutdown has finished")
-			exitChanel <- 0
-		} else {
-			log.Errorf("got unknown signal '%s'", signal)
-			exitChanel <- 1
+func handleLifecycle(server *server, signalChanel <-chan os.Signal) {
+
+	for signal := range signalChanel {
+		if signal == syscall.SIGHUP {
+			lifecycleLog.Infof("Got signal '%s', reloading the application configuration", signal)
+			before := config.snapshot()
+			config.initAppConfig(config.ready)
+			lifecycleLog.Info(config)
+			history.recordDiff("reload", before, config.snapshot())
+			history.recordEnvOverrides(config)
+			continue
 		}
-	}(signalChanel, exitChanel)
-
-	exitCode := <-exitChanel
-	os.Exit(exitCode)
+		lifecycleLog.Infof("Got signal '%s'", signal)
+		os.Exit(server.shutdown())
+	}
 }
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"os"
+	"strings"
+)
+
+const serviceAccountNamespaceFilePath = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+
+type podMetadata struct {
+	Hostname  string
+	PodName   string
+	Namespace string
+}
+
+// newPodMetadata collects info about the pod the application is running in. The downward API environment
+// variables 'POD_NAME' and 'POD_NAMESPACE' take precedence, otherwise the hostname and the namespace of the
+// mounted service account are used.
+func newPodMetadata() *podMetadata {
+	hostname, _ := os.Hostname()
+	podName, podNameExists := os.LookupEnv("POD_NAME")
+	if !podNameExists {
+		podName = hostname
+	}
+	namespace, namespaceExists := os.LookupEnv("POD_NAMESPACE")
+	if !namespaceExists {
+		content, err := os.ReadFile(serviceAccountNamespaceFilePath)
+		if err == nil {
+			namespace = strings.TrimSpace(string(content))
+		}
+	}
+	return &podMetadata{
+		Hostname:  hostname,
+		PodName:   podName,
+		Namespace: namespace,
+	}
+}
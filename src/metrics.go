@@ -0,0 +1,130 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const metricsNamespace = "training_application"
+
+type metrics struct {
+	registry        *prometheus.Registry
+	requestsTotal   *prometheus.CounterVec
+	requestDuration *prometheus.HistogramVec
+	custom          *customMetrics
+}
+
+// buildInfoCollector reports name and version as labels on every scrape, as both can change on 'init'.
+type buildInfoCollector struct {
+	config *appConfig
+	desc   *prometheus.Desc
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	statusCode   int
+	bytesWritten int
+}
+
+func newMetrics(appConfig *appConfig) *metrics {
+	m := &metrics{
+		registry: prometheus.NewRegistry(),
+		requestsTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "http_requests_total",
+			Help:      "Number of handled HTTP requests by route and status code.",
+		}, []string{"route", "code"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Latency of handled HTTP requests by route and status code.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"route", "code"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.requestsTotal,
+		m.requestDuration,
+		&buildInfoCollector{
+			config: appConfig,
+			desc:   prometheus.NewDesc(metricsNamespace+"_build_info", "Name and version of the application.", []string{"name", "version"}, nil),
+		},
+		newGaugeFunc("ready", "Readiness of the application (1 = ready).", func() float64 { return boolToFloat(appConfig.ready) }),
+		newGaugeFunc("alive", "Liveness of the application (1 = alive).", func() float64 { return boolToFloat(appConfig.alive) }),
+		newGaugeFunc("started", "Startup state of the application (1 = started).", func() float64 { return boolToFloat(appConfig.started) }),
+		newGaugeFunc("root_enabled", "State of the root endpoint (1 = enabled).", func() float64 { return boolToFloat(appConfig.rootEnabled) }),
+		newGaugeFunc("root_delay_seconds", "Delay of the root endpoint in seconds.", func() float64 { return float64(appConfig.rootDelaySeconds) }),
+		newGaugeFunc("leaked_memory_entries", "Number of entries leaked by 'leak mem'.", func() float64 { return float64(leakedMemEntries.Load()) }),
+		newGaugeFunc("leaked_cpu_goroutines", "Number of cpu intensive goroutines spawned by 'leak cpu'.", func() float64 { return float64(leakedCpuGoroutines.Load()) }),
+	)
+	m.custom = newCustomMetrics(m.registry)
+	return m
+}
+
+func newGaugeFunc(name, help string, function func() float64) prometheus.GaugeFunc {
+	return prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      name,
+		Help:      help,
+	}, function)
+}
+
+func boolToFloat(value bool) float64 {
+	if value {
+		return 1
+	}
+	return 0
+}
+
+func (m *metrics) handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// instrument counts the requests and observes their latency, labeled with the matched route of the mux.
+func (m *metrics) instrument(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w, statusCode: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+
+		route := r.Pattern
+		if route == "" {
+			route = "unmatched"
+		}
+		code := strconv.Itoa(recorder.statusCode)
+		m.requestsTotal.WithLabelValues(route, code).Inc()
+		m.requestDuration.WithLabelValues(route, code).Observe(time.Since(start).Seconds())
+	})
+}
+
+func (c *buildInfoCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *buildInfoCollector) Collect(ch chan<- prometheus.Metric) {
+	ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, 1,
+		c.config.redact("name", c.config.applicationName), c.config.redact("version", c.config.applicationVersion))
+}
+
+func (r *statusRecorder) WriteHeader(statusCode int) {
+	r.statusCode = statusCode
+	r.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (r *statusRecorder) Write(bytes []byte) (int, error) {
+	n, err := r.ResponseWriter.Write(bytes)
+	r.bytesWritten += n
+	return n, err
+}
+
+// Unwrap gives http.ResponseController access to the underlying response writer, eg for flushing.
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	formatHTML = "html"
+	formatJSON = "json"
+	formatText = "text"
+)
+
+var mediaTypeFormats = map[string]string{
+	"text/html":             formatHTML,
+	"application/xhtml+xml": formatHTML,
+	"application/json":      formatJSON,
+	"text/plain":            formatText,
+	"text/*":                formatText,
+	"*/*":                   formatText,
+}
+
+type mediaRange struct {
+	mediaType string
+	quality   float64
+}
+
+// negotiateFormat picks the response format from the 'format' query parameter or the 'Accept' header.
+// Browsers explicitly ask for HTML, clients like curl accepting anything get plain text.
+func negotiateFormat(r *http.Request) (string, error) {
+	if format := r.URL.Query().Get("format"); format != "" {
+		switch format {
+		case formatHTML, formatJSON, formatText:
+			return format, nil
+		default:
+			return "", fmt.Errorf("unknown format '%s', use one of '%s', '%s' or '%s'", format, formatHTML, formatJSON, formatText)
+		}
+	}
+
+	accept := r.Header.Get("Accept")
+	if accept == "" {
+		return formatText, nil
+	}
+	for _, mediaRange := range parseAccept(accept) {
+		if format, known := mediaTypeFormats[mediaRange.mediaType]; known && mediaRange.quality > 0 {
+			return format, nil
+		}
+	}
+	return formatHTML, nil
+}
+
+// parseAccept parses the media ranges of an 'Accept' header, ordered by descending quality.
+func parseAccept(accept string) []*mediaRange {
+	mediaRanges := []*mediaRange{}
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, _ := strings.Cut(part, ";")
+		quality := 1.0
+		for _, param := range strings.Split(params, ";") {
+			key, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if key == "q" {
+				if q, err := strconv.ParseFloat(value, 64); err == nil {
+					quality = q
+				}
+			}
+		}
+		mediaRanges = append(mediaRanges, &mediaRange{
+			mediaType: strings.ToLower(strings.TrimSpace(mediaType)),
+			quality:   quality,
+		})
+	}
+	sort.SliceStable(mediaRanges, func(i, j int) bool { return mediaRanges[i].quality > mediaRanges[j].quality })
+	return mediaRanges
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseAccept(t *testing.T) {
+	tests := []struct {
+		name   string
+		accept string
+		want   []mediaRange
+	}{
+		{"single", "text/html", []mediaRange{{"text/html", 1}}},
+		{"ordered by quality", "text/plain;q=0.5, application/json", []mediaRange{{"application/json", 1}, {"text/plain", 0.5}}},
+		{"stable for equal quality", "text/html, application/xhtml+xml", []mediaRange{{"text/html", 1}, {"application/xhtml+xml", 1}}},
+		{"lower case and trimmed", " Application/JSON ; q=0.8", []mediaRange{{"application/json", 0.8}}},
+		{"invalid quality", "text/html;q=high", []mediaRange{{"text/html", 1}}},
+		{"other parameters", "text/html;level=1;q=0.2", []mediaRange{{"text/html", 0.2}}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := parseAccept(test.accept)
+			if len(got) != len(test.want) {
+				t.Fatalf("parseAccept(%q) returned %d media ranges, want %d", test.accept, len(got), len(test.want))
+			}
+			for i := range got {
+				if *got[i] != test.want[i] {
+					t.Errorf("parseAccept(%q)[%d] = %v, want %v", test.accept, i, *got[i], test.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestNegotiateFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		target  string
+		accept  string
+		want    string
+		wantErr bool
+	}{
+		{"no accept header", "/", "", formatText, false},
+		{"curl", "/", "*/*", formatText, false},
+		{"browser", "/", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", formatHTML, false},
+		{"json", "/", "application/json", formatJSON, false},
+		{"preferred by quality", "/", "text/html;q=0.5, application/json", formatJSON, false},
+		{"zero quality is skipped", "/", "application/json;q=0, text/plain;q=0.1", formatText, false},
+		{"unknown media type", "/", "image/png", formatHTML, false},
+		{"query parameter over accept header", "/?format=json", "text/html", formatJSON, false},
+		{"unknown query parameter", "/?format=xml", "", "", true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, test.target, nil)
+			if test.accept != "" {
+				r.Header.Set("Accept", test.accept)
+			}
+			got, err := negotiateFormat(r)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("negotiateFormat() error = %v, wantErr %v", err, test.wantErr)
+			}
+			if got != test.want {
+				t.Errorf("negotiateFormat() = %s, want %s", got, test.want)
+			}
+		})
+	}
+}
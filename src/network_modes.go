@@ -0,0 +1,248 @@
+package main
+
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pires/go-proxyproto"
+)
+
+const slowChunks = 10
+const throttleInterval = 100 * time.Millisecond
+
+var networkModeTypes = []string{"throttle", "slow", "stall", "reset"}
+
+// networkMode shapes the responses of a path, configured as '<path>:<mode>[:<param>]', eg '/bytes/*:throttle:1024'.
+// Paths ending with '/*' apply to all paths below them, otherwise only to the path itself.
+//   - throttle:<bytes per second> sends the body at the given bandwidth
+//   - slow:<duration> sends the body in small chunks spread over the duration
+//   - stall:<duration> sends the first half of the body and stalls for the duration before sending the rest
+//   - reset sends the first half of the body and resets the TCP connection
+type networkMode struct {
+	path           string
+	mode           string
+	bytesPerSecond int
+	duration       time.Duration
+}
+
+func (nm *networkMode) String() string {
+	switch nm.mode {
+	case "throttle":
+		return fmt.Sprintf("%s:%s:%d", nm.path, nm.mode, nm.bytesPerSecond)
+	case "slow", "stall":
+		return fmt.Sprintf("%s:%s:%s", nm.path, nm.mode, nm.duration)
+	default:
+		return fmt.Sprintf("%s:%s", nm.path, nm.mode)
+	}
+}
+
+func (appConfig *appConfig) networkModeNames() []string {
+	names := []string{}
+	for _, networkMode := range appConfig.networkModes {
+		names = append(names, networkMode.String())
+	}
+	return names
+}
+
+func newNetworkMode(path, mode, param string) (*networkMode, error) {
+	if !strings.HasPrefix(path, "/") {
+		return nil, fmt.Errorf("invalid path '%s', it has to start with '/'", path)
+	}
+	networkMode := &networkMode{path: path, mode: mode}
+	switch mode {
+	case "throttle":
+		bytesPerSecond, err := strconv.Atoi(param)
+		if err != nil || bytesPerSecond < 1 {
+			return nil, fmt.Errorf("invalid bytes per second '%s' of mode 'throttle', eg 'throttle:1024'", param)
+		}
+		networkMode.bytesPerSecond = bytesPerSecond
+	case "slow", "stall":
+		duration, err := parseSecondsOrDuration(param)
+		if err != nil || duration <= 0 {
+			return nil, fmt.Errorf("invalid duration '%s' of mode '%s', eg '%s:5s'", param, mode, mode)
+		}
+		networkMode.duration = duration
+	case "reset":
+		if param != "" {
+			return nil, fmt.Errorf("mode 'reset' takes no parameter")
+		}
+	default:
+		return nil, fmt.Errorf("unknown network mode '%s', use one of '%s'", mode, strings.Join(networkModeTypes, "', '"))
+	}
+	return networkMode, nil
+}
+
+// parseNetworkModes parses the comma separated list of network modes, invalid ones are skipped.
+func parseNetworkModes(value string) []*networkMode {
+	networkModes := []*networkMode{}
+	for _, item := range splitList(value) {
+		parts := strings.SplitN(item, ":", 3)
+		if len(parts) < 2 {
+			configLog.Errorf("could not configure network mode '%s', use '<path>:<mode>[:<param>]', eg '/bytes/*:throttle:1024'", item)
+			continue
+		}
+		param := ""
+		if len(parts) == 3 {
+			param = parts[2]
+		}
+		networkMode, err := newNetworkMode(parts[0], parts[1], param)
+		if err != nil {
+			configLog.Errorf("could not configure network mode '%s': %s", item, err)
+			continue
+		}
+		networkModes = append(networkModes, networkMode)
+	}
+	return networkModes
+}
+
+// setNetworkMode replaces the network mode of the path, a nil mode removes it.
+func (appConfig *appConfig) setNetworkMode(path string, mode *networkMode) {
+	networkModes := slices.DeleteFunc(slices.Clone(appConfig.networkModes), func(networkMode *networkMode) bool {
+		return networkMode.path == path
+	})
+	if mode != nil {
+		networkModes = append(networkModes, mode)
+	}
+	appConfig.networkModes = networkModes
+}
+
+// lookupNetworkMode returns the network mode of the path itself or else the one of the longest matching '/*' prefix.
+func (appConfig *appConfig) lookupNetworkMode(path string) *networkMode {
+	var match *networkMode
+	for _, networkMode := range appConfig.networkModes {
+		if path == networkMode.path {
+			return networkMode
+		}
+		prefix, isSubtree := strings.CutSuffix(networkMode.path, "*")
+		if isSubtree && strings.HasSuffix(prefix, "/") && strings.HasPrefix(path, prefix) && (match == nil || len(networkMode.path) > len(match.path)) {
+			match = networkMode
+		}
+	}
+	return match
+}
+
+// bufferedResponseWriter keeps the body of the response, so it can be sent shaped by the network mode afterwards.
+type bufferedResponseWriter struct {
+	http.ResponseWriter
+	statusCode int
+	body       bytes.Buffer
+}
+
+func (b *bufferedResponseWriter) WriteHeader(statusCode int) {
+	if b.statusCode == 0 {
+		b.statusCode = statusCode
+	}
+}
+
+func (b *bufferedResponseWriter) Write(bytes []byte) (int, error) {
+	return b.body.Write(bytes)
+}
+
+// Flush is a no-op, which lets streaming handlers run while their response is buffered.
+func (b *bufferedResponseWriter) Flush() {}
+
+// shapeNetwork sends the responses of paths with a network mode shaped, the headers are sent right away.
+func (s *server) shapeNetwork(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		networkMode := s.config.lookupNetworkMode(r.URL.Path)
+		if networkMode == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		buffered := &bufferedResponseWriter{ResponseWriter: w}
+		next.ServeHTTP(buffered, r)
+		if buffered.statusCode == 0 {
+			buffered.statusCode = http.StatusOK
+		}
+		body := buffered.body.Bytes()
+		if len(body) > 0 {
+			w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		}
+		w.WriteHeader(buffered.statusCode)
+		if len(body) == 0 {
+			return
+		}
+
+		requestLog(r).Infof("Sending %d bytes with network mode '%s'", len(body), networkMode)
+		rc := http.NewResponseController(w)
+		half := len(body) / 2
+		var err error
+		switch networkMode.mode {
+		case "throttle":
+			chunkSize := max(networkMode.bytesPerSecond*int(throttleInterval)/int(time.Second), 1)
+			interval := time.Duration(chunkSize) * time.Second / time.Duration(networkMode.bytesPerSecond)
+			err = writeChunks(w, r, body, chunkSize, interval)
+		case "slow":
+			chunkSize := (len(body) + slowChunks - 1) / slowChunks
+			chunks := (len(body) + chunkSize - 1) / chunkSize
+			err = writeChunks(w, r, body, chunkSize, networkMode.duration/time.Duration(chunks))
+		case "stall":
+			err = writeChunks(w, r, body[:half], half, 0)
+			if err == nil {
+				requestLog(r).Infof("Stalling the response for %s after %d bytes", networkMode.duration, half)
+				err = writeChunks(w, r, body[half:], len(body)-half, networkMode.duration)
+			}
+		case "reset":
+			err = writeChunks(w, r, body[:half], half, 0)
+			if err == nil {
+				requestLog(r).Infof("Resetting the connection after %d bytes", half)
+				resetConnection(rc)
+			}
+		}
+		if err != nil {
+			requestLog(r).Errorf("error on sending the response with network mode '%s': %s", networkMode, err)
+		}
+	})
+}
+
+// writeChunks waits for the interval before sending every chunk and flushes it, it stops if the client is gone.
+func writeChunks(w http.ResponseWriter, r *http.Request, body []byte, chunkSize int, interval time.Duration) error {
+	rc := http.NewResponseController(w)
+	for start := 0; start < len(body); start += chunkSize {
+		if interval > 0 {
+			select {
+			case <-time.After(interval):
+			case <-r.Context().Done():
+				return r.Context().Err()
+			}
+		}
+		if _, err := w.Write(body[start:min(start+chunkSize, len(body))]); err != nil {
+			return err
+		}
+		if err := rc.Flush(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resetConnection closes the TCP connection with a RST instead of a FIN. Connections which can't be hijacked,
+// like HTTP/2 and HTTP/3 streams, are aborted instead.
+func resetConnection(rc *http.ResponseController) {
+	conn, _, err := rc.Hijack()
+	if err != nil {
+		panic(http.ErrAbortHandler)
+	}
+	netConn := conn
+	if tlsConn, isTls := netConn.(*tls.Conn); isTls {
+		netConn = tlsConn.NetConn()
+	}
+	if proxyConn, isProxyConn := netConn.(*proxyproto.Conn); isProxyConn {
+		netConn = proxyConn.Raw()
+	}
+	if tcpConn, isTcp := netConn.(*net.TCPConn); isTcp {
+		if err := tcpConn.SetLinger(0); err != nil {
+			serverLog.Errorf("error on resetting the connection: %v", err)
+		}
+	}
+	if err := netConn.Close(); err != nil {
+		serverLog.Errorf("error on closing: %v", err)
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestParseNetworkModes(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  []networkMode
+	}{
+		{"empty", "", []networkMode{}},
+		{"throttle", "/bytes/*:throttle:1024", []networkMode{{path: "/bytes/*", mode: "throttle", bytesPerSecond: 1024}}},
+		{"slow in seconds", "/:slow:5", []networkMode{{path: "/", mode: "slow", duration: 5 * time.Second}}},
+		{"stall as duration", "/echo:stall:1500ms", []networkMode{{path: "/echo", mode: "stall", duration: 1500 * time.Millisecond}}},
+		{"reset", "/echo:reset", []networkMode{{path: "/echo", mode: "reset"}}},
+		{"several", "/:stall:10s, /echo:reset", []networkMode{{path: "/", mode: "stall", duration: 10 * time.Second}, {path: "/echo", mode: "reset"}}},
+		{"invalid ones are skipped", "/:reset,missing-mode,echo:reset,/:unknown,/:throttle:0,/:throttle:fast,/:slow:-1s,/:stall,/:reset:1", []networkMode{{path: "/", mode: "reset"}}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := parseNetworkModes(test.value)
+			if len(got) != len(test.want) {
+				t.Fatalf("parseNetworkModes(%q) returned %d network modes, want %d", test.value, len(got), len(test.want))
+			}
+			for i := range got {
+				if *got[i] != test.want[i] {
+					t.Errorf("parseNetworkModes(%q)[%d] = %v, want %v", test.value, i, got[i], &test.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestNetworkModeString(t *testing.T) {
+	value := "/bytes/*:throttle:1024,/:slow:1.5s,/echo:reset"
+	appConfig := &appConfig{networkModes: parseNetworkModes(value)}
+	if got, want := appConfig.networkModeNames(), []string{"/bytes/*:throttle:1024", "/:slow:1.5s", "/echo:reset"}; !slices.Equal(got, want) {
+		t.Errorf("networkModeNames() = %v, want %v", got, want)
+	}
+}
+
+func TestLookupNetworkMode(t *testing.T) {
+	subtreeConfig := &appConfig{networkModes: parseNetworkModes("/:stall:1s,/*:slow:1s,/api/*:reset,/api/config/*:throttle:10,/echo:reset")}
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/", "/:stall:1s"},
+		{"/echo", "/echo:reset"},
+		{"/readiness", "/*:slow:1s"},
+		{"/api/config", "/api/*:reset"},
+		{"/api/config/history", "/api/config/*:throttle:10"},
+	}
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			got := subtreeConfig.lookupNetworkMode(test.path)
+			if got == nil || got.String() != test.want {
+				t.Errorf("lookupNetworkMode(%s) = %v, want %s", test.path, got, test.want)
+			}
+		})
+	}
+
+	exactConfig := &appConfig{networkModes: parseNetworkModes("/:stall:1s,/bytes/:throttle:10")}
+	for _, path := range []string{"/readiness", "/bytes/1024"} {
+		if got := exactConfig.lookupNetworkMode(path); got != nil {
+			t.Errorf("lookupNetworkMode(%s) = %v, want none", path, got)
+		}
+	}
+}
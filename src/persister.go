@@ -4,8 +4,6 @@ import (
 	"fmt"
 	"os"
 	"time"
-
-	log "github.com/sirupsen/logrus"
 )
 
 type persister struct {
@@ -28,7 +26,7 @@ func (p *persister) writeMetaInfo() {
 
 	metaInfoFile, err := os.OpenFile(metaInfoFilePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 	if err != nil {
-		log.Errorf("cannot open file %s: %v\n", metaInfoFilePath, err)
+		persisterLog.Errorf("cannot open file %s: %v\n", metaInfoFilePath, err)
 	}
 	defer metaInfoFile.Close()
 
@@ -46,7 +44,7 @@ func (p *persister) writeMetaInfo() {
 			timeStamp := time.Now().Format("2006-01-02 15:04:05")
 			_, err = fmt.Fprintf(metaInfoFile, "%s worker node name %s, pod name %s, pod ip %s\n", timeStamp, workerNodeName, podName, podIP)
 			if err != nil {
-				log.Errorf("cannot append to file %s: %v\n", metaInfoFilePath, err)
+				persisterLog.Errorf("cannot append to file %s: %v\n", metaInfoFilePath, err)
 				return
 			}
 		}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/quic-go/quic-go/http3"
+)
+
+var requestProtocols = []string{"--http1.1", "--http2", "--http3"}
+
+// newHttp3Server serves HTTP/3 over QUIC on the UDP port with the same number as 'tlsPort'.
+func newHttp3Server(port int, handler http.Handler, tlsConfig *tls.Config) *http3.Server {
+	return &http3.Server{
+		Addr:      ":" + strconv.Itoa(port),
+		Handler:   handler,
+		TLSConfig: tlsConfig,
+	}
+}
+
+// advertiseHttp3 sets the 'Alt-Svc' header on responses via HTTP/1.1 and HTTP/2, which tells clients like
+// browsers that they can switch to HTTP/3.
+func (s *server) advertiseHttp3(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := s.http3Server.SetQUICHeaders(w.Header()); err != nil {
+			requestLog(r).Errorf("error on setting the 'Alt-Svc' header: %s", err)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// newCleartextProtocols allows HTTP/2 without TLS (h2c) with prior knowledge next to HTTP/1.1.
+func newCleartextProtocols() *http.Protocols {
+	protocols := &http.Protocols{}
+	protocols.SetHTTP1(true)
+	protocols.SetUnencryptedHTTP2(true)
+	return protocols
+}
+
+// newRequestTransport returns the transport of the 'request' command, which forces the protocol version
+// if given: '--http1.1', '--http2' (via TLS or h2c with prior knowledge) or '--http3'.
+func (cli *cli) newRequestTransport(protocol string) (http.RoundTripper, error) {
+	tlsConfig := &tls.Config{RootCAs: cli.requestCAs.Load()}
+	if protocol == "--http3" {
+		return &http3.Transport{TLSClientConfig: tlsConfig}, nil
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	switch protocol {
+	case "":
+	case "--http1.1":
+		transport.Protocols = &http.Protocols{}
+		transport.Protocols.SetHTTP1(true)
+	case "--http2":
+		transport.Protocols = &http.Protocols{}
+		transport.Protocols.SetHTTP2(true)
+		transport.Protocols.SetUnencryptedHTTP2(true)
+	default:
+		return nil, fmt.Errorf("unknown protocol '%s', use one of '%s'", protocol, strings.Join(requestProtocols, "', '"))
+	}
+	return transport, nil
+}
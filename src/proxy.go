@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/pires/go-proxyproto"
+)
+
+const connContextKey contextKey = "conn"
+const clientInfoContextKey contextKey = "clientInfo"
+
+// clientInfo is the client as seen through the proxies in front of the application.
+type clientInfo struct {
+	ip   string
+	hops []string
+}
+
+// parseTrustedProxies parses the comma separated list of CIDRs and IPs, invalid ones are skipped.
+func parseTrustedProxies(value string) []*net.IPNet {
+	trustedProxies := []*net.IPNet{}
+	for _, item := range splitList(value) {
+		cidr := item
+		if ip := net.ParseIP(item); ip != nil && ip.To4() != nil {
+			cidr += "/32"
+		} else if ip != nil {
+			cidr += "/128"
+		}
+		_, trustedProxy, err := net.ParseCIDR(cidr)
+		if err != nil {
+			configLog.Errorf("could not configure trusted proxy '%s', use a CIDR or an IP", item)
+			continue
+		}
+		trustedProxies = append(trustedProxies, trustedProxy)
+	}
+	return trustedProxies
+}
+
+func (appConfig *appConfig) trustedProxyNames() []string {
+	names := []string{}
+	for _, trustedProxy := range appConfig.trustedProxies {
+		names = append(names, trustedProxy.String())
+	}
+	return names
+}
+
+func (appConfig *appConfig) isTrustedProxy(address string) bool {
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return false
+	}
+	for _, trustedProxy := range appConfig.trustedProxies {
+		if trustedProxy.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// listen opens a TCP listener, which accepts PROXY protocol v1 and v2 headers if 'proxyProtocol' is set.
+// The header is optional, eg for probes of the kubelet, and only used if sent by a trusted proxy, as long
+// as 'trustedProxies' are configured.
+func (s *server) listen(address string) (net.Listener, error) {
+	listener, err := net.Listen("tcp", address)
+	if err != nil || !s.config.proxyProtocol {
+		return listener, err
+	}
+	return &proxyproto.Listener{
+		Listener: listener,
+		ConnPolicy: func(options proxyproto.ConnPolicyOptions) (proxyproto.Policy, error) {
+			if len(s.config.trustedProxies) == 0 {
+				return proxyproto.USE, nil
+			}
+			host, _, _ := net.SplitHostPort(options.Upstream.String())
+			if s.config.isTrustedProxy(host) {
+				return proxyproto.USE, nil
+			}
+			return proxyproto.IGNORE, nil
+		},
+	}, nil
+}
+
+// withConn makes the connection available to the handlers, which is needed to show the peer of a
+// connection using the PROXY protocol.
+func withConn(ctx context.Context, conn net.Conn) context.Context {
+	return context.WithValue(ctx, connContextKey, conn)
+}
+
+// proxyProtocolPeer returns the address of the proxy which sent a PROXY protocol header, otherwise "".
+func proxyProtocolPeer(r *http.Request) string {
+	conn, _ := r.Context().Value(connContextKey).(net.Conn)
+	if tlsConn, isTls := conn.(*tls.Conn); isTls {
+		conn = tlsConn.NetConn()
+	}
+	proxyConn, isProxyConn := conn.(*proxyproto.Conn)
+	if !isProxyConn || proxyConn.ProxyHeader() == nil {
+		return ""
+	}
+	host, _, _ := net.SplitHostPort(proxyConn.Raw().RemoteAddr().String())
+	return host
+}
+
+// forwardedHops returns the hops recorded by proxies, from the 'Forwarded' header or, if missing, from the
+// 'X-Forwarded-For' or 'X-Real-IP' header.
+func forwardedHops(header http.Header) []string {
+	hops := []string{}
+	for _, forwarded := range header.Values("Forwarded") {
+		for _, element := range strings.Split(forwarded, ",") {
+			for _, pair := range strings.Split(element, ";") {
+				key, value, _ := strings.Cut(strings.TrimSpace(pair), "=")
+				if strings.EqualFold(key, "for") {
+					hops = append(hops, stripPort(strings.Trim(value, "\"")))
+				}
+			}
+		}
+	}
+	if len(hops) > 0 {
+		return hops
+	}
+	for _, forwardedFor := range header.Values("X-Forwarded-For") {
+		hops = append(hops, splitList(forwardedFor)...)
+	}
+	if len(hops) > 0 {
+		return hops
+	}
+	if realIp := strings.TrimSpace(header.Get("X-Real-IP")); realIp != "" {
+		hops = append(hops, realIp)
+	}
+	return hops
+}
+
+// stripPort strips the port of addresses like '192.0.2.1:4711' or '[2001:db8::1]:4711'.
+func stripPort(address string) string {
+	if host, _, err := net.SplitHostPort(address); err == nil {
+		return host
+	}
+	return strings.Trim(address, "[]")
+}
+
+// resolveClient walks the hops from the address of the connection towards the client and stops at the
+// first hop which is not a trusted proxy. Without trusted proxies, the client is the address of the connection.
+func (appConfig *appConfig) resolveClient(r *http.Request) *clientInfo {
+	hops := append(forwardedHops(r.Header), stripPort(r.RemoteAddr))
+	i := len(hops) - 1
+	for i > 0 && appConfig.isTrustedProxy(hops[i]) {
+		i--
+	}
+	client := &clientInfo{ip: hops[i], hops: hops}
+	if peer := proxyProtocolPeer(r); peer != "" {
+		client.hops = append(client.hops, peer)
+	}
+	return client
+}
+
+func (s *server) withClientInfo(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), clientInfoContextKey, s.config.resolveClient(r))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/pires/go-proxyproto"
+)
+
+func TestForwardedHops(t *testing.T) {
+	tests := []struct {
+		name   string
+		header http.Header
+		want   []string
+	}{
+		{"no headers", http.Header{}, []string{}},
+		{"forwarded", http.Header{"Forwarded": {"for=192.0.2.1;proto=https, for=10.0.0.1"}}, []string{"192.0.2.1", "10.0.0.1"}},
+		{"forwarded ipv6 with port", http.Header{"Forwarded": {`for="[2001:db8::1]:4711"`}}, []string{"2001:db8::1"}},
+		{"forwarded ipv4 with port", http.Header{"Forwarded": {`For="192.0.2.1:4711"`}}, []string{"192.0.2.1"}},
+		{"forwarded over x-forwarded-for", http.Header{"Forwarded": {"for=192.0.2.1"}, "X-Forwarded-For": {"192.0.2.2"}}, []string{"192.0.2.1"}},
+		{"x-forwarded-for", http.Header{"X-Forwarded-For": {"192.0.2.1, 10.0.0.1", "10.0.0.2"}}, []string{"192.0.2.1", "10.0.0.1", "10.0.0.2"}},
+		{"x-forwarded-for over x-real-ip", http.Header{"X-Forwarded-For": {"192.0.2.1"}, "X-Real-Ip": {"192.0.2.2"}}, []string{"192.0.2.1"}},
+		{"x-real-ip", http.Header{"X-Real-Ip": {" 192.0.2.2 "}}, []string{"192.0.2.2"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := forwardedHops(test.header); !slices.Equal(got, test.want) {
+				t.Errorf("forwardedHops() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestResolveClient(t *testing.T) {
+	tests := []struct {
+		name           string
+		trustedProxies string
+		remoteAddr     string
+		header         http.Header
+		wantIp         string
+		wantHops       []string
+	}{
+		{"no proxies", "", "192.0.2.1:4711", http.Header{}, "192.0.2.1", []string{"192.0.2.1"}},
+		{"untrusted forwarded for", "", "10.0.0.1:4711", http.Header{"X-Forwarded-For": {"192.0.2.1"}}, "10.0.0.1", []string{"192.0.2.1", "10.0.0.1"}},
+		{"trusted proxy", "10.0.0.0/8", "10.0.0.1:4711", http.Header{"X-Forwarded-For": {"192.0.2.1"}}, "192.0.2.1", []string{"192.0.2.1", "10.0.0.1"}},
+		{"spoofed hop before untrusted hop", "10.0.0.1", "10.0.0.1:4711", http.Header{"X-Forwarded-For": {"203.0.113.9, 192.0.2.1"}}, "192.0.2.1", []string{"203.0.113.9", "192.0.2.1", "10.0.0.1"}},
+		{"every hop trusted", "10.0.0.0/8", "10.0.0.1:4711", http.Header{"X-Forwarded-For": {"10.1.1.1, 10.2.2.2"}}, "10.1.1.1", []string{"10.1.1.1", "10.2.2.2", "10.0.0.1"}},
+		{"ipv6 forwarded", "::1", "[::1]:4711", http.Header{"Forwarded": {`for="[2001:db8::1]:4711"`}}, "2001:db8::1", []string{"2001:db8::1", "::1"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			appConfig := &appConfig{trustedProxies: parseTrustedProxies(test.trustedProxies)}
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = test.remoteAddr
+			r.Header = test.header
+			client := appConfig.resolveClient(r)
+			if client.ip != test.wantIp {
+				t.Errorf("resolveClient().ip = %s, want %s", client.ip, test.wantIp)
+			}
+			if !slices.Equal(client.hops, test.wantHops) {
+				t.Errorf("resolveClient().hops = %v, want %v", client.hops, test.wantHops)
+			}
+		})
+	}
+}
+
+func TestResolveClientProxyProtocolPeer(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	proxyListener := &proxyproto.Listener{Listener: listener}
+	defer func() { _ = proxyListener.Close() }()
+
+	go func() {
+		conn, err := net.Dial("tcp", listener.Addr().String())
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.Close() }()
+		_, _ = io.WriteString(conn, "PROXY TCP4 192.0.2.5 10.0.0.1 5555 80\r\n")
+		_, _ = io.Copy(io.Discard, conn)
+	}()
+	conn, err := proxyListener.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = conn.Close() }()
+
+	appConfig := &appConfig{trustedProxies: parseTrustedProxies("")}
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = conn.RemoteAddr().String()
+	r = r.WithContext(withConn(context.Background(), conn))
+	client := appConfig.resolveClient(r)
+	if client.ip != "192.0.2.5" {
+		t.Errorf("resolveClient().ip = %s, want 192.0.2.5", client.ip)
+	}
+	if want := []string{"192.0.2.5", "127.0.0.1"}; !slices.Equal(client.hops, want) {
+		t.Errorf("resolveClient().hops = %v, want %v", client.hops, want)
+	}
+}
+
+func TestParseTrustedProxies(t *testing.T) {
+	got := []string{}
+	for _, trustedProxy := range parseTrustedProxies("10.0.0.0/8, 192.0.2.1,2001:db8::1,invalid") {
+		got = append(got, trustedProxy.String())
+	}
+	if want := []string{"10.0.0.0/8", "192.0.2.1/32", "2001:db8::1/128"}; !slices.Equal(got, want) {
+		t.Errorf("parseTrustedProxies() = %v, want %v", got, want)
+	}
+}
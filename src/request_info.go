@@ -8,25 +8,33 @@ import (
 )
 
 type requestInfo struct {
-	Method     string
-	Url        string
-	Proto      string
-	Host       string
-	RemoteAddr string
-	RequestUri string
-	TLS        bool
-	Header     map[string][]string
+	Method     string              `json:"method"`
+	Url        string              `json:"url"`
+	Proto      string              `json:"proto"`
+	Host       string              `json:"host"`
+	RemoteAddr string              `json:"remoteAddr"`
+	ClientIp   string              `json:"clientIp"`
+	Hops       []string            `json:"hops"`
+	RequestUri string              `json:"requestUri"`
+	TLS        *tlsInfo            `json:"tls"`
+	Header     map[string][]string `json:"header"`
 }
 
 func newRequestInfo(r *http.Request) *requestInfo {
+	clientIp, hops := "", []string{}
+	if clientInfo, ok := r.Context().Value(clientInfoContextKey).(*clientInfo); ok {
+		clientIp, hops = clientInfo.ip, clientInfo.hops
+	}
 	return &requestInfo{
 		Method:     r.Method,
 		Url:        r.URL.String(),
 		Proto:      r.Proto,
 		Host:       r.Host,
 		RemoteAddr: r.RemoteAddr,
+		ClientIp:   clientIp,
+		Hops:       hops,
 		RequestUri: r.RequestURI,
-		TLS:        r.TLS != nil,
+		TLS:        newTLSInfo(r.TLS),
 		Header:     r.Header,
 	}
 }
@@ -39,8 +47,14 @@ func (ri *requestInfo) String() string {
 	sb.WriteString(fmt.Sprintf("\tProto:      %v\n", ri.Proto))
 	sb.WriteString(fmt.Sprintf("\tHost:       %v\n", ri.Host))
 	sb.WriteString(fmt.Sprintf("\tRemoteAddr: %v\n", ri.RemoteAddr))
+	sb.WriteString(fmt.Sprintf("\tClientIp:   %v\n", ri.ClientIp))
+	sb.WriteString(fmt.Sprintf("\tHops:       %v\n", strings.Join(ri.Hops, " -> ")))
 	sb.WriteString(fmt.Sprintf("\tRequestUri: %v\n", ri.RequestUri))
-	sb.WriteString(fmt.Sprintf("\tTLS:        %v\n", ri.TLS))
+	if ri.TLS == nil {
+		sb.WriteString("\tTLS:        false\n")
+	} else {
+		sb.WriteString(fmt.Sprintf("\tTLS:        %s, %s, SNI '%s'\n", ri.TLS.Version, ri.TLS.CipherSuite, ri.TLS.ServerName))
+	}
 	sb.WriteString("\tHeader:\n")
 	for key, value := range ri.Header {
 		sb.WriteString(fmt.Sprintf("\t\t%v: %v\n", key,value))
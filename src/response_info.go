@@ -10,50 +10,84 @@ import (
 )
 
 type certInfo struct {
-	Subject        string
-	Issuer         string
+	Subject  string   `json:"subject"`
+	Issuer   string   `json:"issuer"`
+	SANs     []string `json:"sans"`
+	SpiffeId string   `json:"spiffeId"`
 }
 
 type tlsInfo struct {
-	ServerName  string
-	CertInfos   []*certInfo
+	Version            string      `json:"version"`
+	CipherSuite        string      `json:"cipherSuite"`
+	NegotiatedProtocol string      `json:"negotiatedProtocol"`
+	ServerName         string      `json:"serverName"`
+	CertInfos          []*certInfo `json:"certificates"`
+	Verified           bool        `json:"verified"`
 }
 
 type responseInfo struct {
-	Status     string
-	Proto      string
-	TlsInfo    *tlsInfo
-	Header     map[string][]string
+	Status  string
+	Proto   string
+	TlsInfo *tlsInfo
+	Header  map[string][]string
 }
 
 func newCertInfos(certs []*x509.Certificate) []*certInfo {
 	certInfos := make([]*certInfo, len(certs))
 	for i, cert := range certs {
 		certInfos[i] = &certInfo{
-			Subject:        strings.TrimSpace(cert.Subject.CommonName),
-			Issuer:         strings.TrimSpace(cert.Issuer.CommonName),
+			Subject:  strings.TrimSpace(cert.Subject.CommonName),
+			Issuer:   strings.TrimSpace(cert.Issuer.CommonName),
+			SANs:     subjectAltNames(cert),
+			SpiffeId: spiffeId(cert),
 		}
-	} 
+	}
 	return certInfos
 }
 
+func subjectAltNames(cert *x509.Certificate) []string {
+	sans := append([]string{}, cert.DNSNames...)
+	for _, ip := range cert.IPAddresses {
+		sans = append(sans, ip.String())
+	}
+	sans = append(sans, cert.EmailAddresses...)
+	for _, uri := range cert.URIs {
+		sans = append(sans, uri.String())
+	}
+	return sans
+}
+
+// spiffeId returns the SPIFFE ID of a workload certificate, which is its URI SAN with the scheme 'spiffe'.
+func spiffeId(cert *x509.Certificate) string {
+	for _, uri := range cert.URIs {
+		if uri.Scheme == "spiffe" {
+			return uri.String()
+		}
+	}
+	return ""
+}
+
 func newTLSInfo(s *tls.ConnectionState) *tlsInfo {
 	if s == nil {
 		return nil
 	} else {
 		return &tlsInfo{
-			ServerName:  s.ServerName,
-			CertInfos:  newCertInfos(s.PeerCertificates),
+			Version:            tls.VersionName(s.Version),
+			CipherSuite:        tls.CipherSuiteName(s.CipherSuite),
+			NegotiatedProtocol: s.NegotiatedProtocol,
+			ServerName:         s.ServerName,
+			CertInfos:          newCertInfos(s.PeerCertificates),
+			Verified:           len(s.VerifiedChains) > 0,
 		}
 	}
 }
 
 func newResponseInfo(r *http.Response) *responseInfo {
 	return &responseInfo{
-		Status:     r.Status,
-		Proto:      r.Proto,
-		TlsInfo:    newTLSInfo(r.TLS),
-		Header:     r.Header,
+		Status:  r.Status,
+		Proto:   r.Proto,
+		TlsInfo: newTLSInfo(r.TLS),
+		Header:  r.Header,
 	}
 }
 
@@ -66,6 +100,8 @@ func (ri *responseInfo) String() string {
 		sb.WriteString("\tTLS: false\n")
 	} else {
 		sb.WriteString("\tTLS:\n")
+		sb.WriteString(fmt.Sprintf("\t\tVersion: %s\n", ri.TlsInfo.Version))
+		sb.WriteString(fmt.Sprintf("\t\tCipher Suite: %s\n", ri.TlsInfo.CipherSuite))
 		sb.WriteString(fmt.Sprintf("\t\tServer Name: %s\n", ri.TlsInfo.ServerName))
 		sb.WriteString("\t\tCertificates:\n")
 		for _, ci := range ri.TlsInfo.CertInfos {
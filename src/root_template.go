@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bytes"
+	"html/template"
+	"os"
+)
+
+// rootTemplate is the template of the root endpoint, either the embedded one or the one loaded from
+// 'templatePath'. If the latter fails to parse, the embedded one is used and err is shown on the page.
+type rootTemplate struct {
+	tmpl *template.Template
+	path string
+	err  error
+}
+
+func (s *server) loadRootTemplate() {
+	path := s.config.templatePath
+	if path == "" {
+		s.rootTmpl.Store(&rootTemplate{tmpl: s.embeddedTmpl})
+		return
+	}
+
+	content, err := os.ReadFile(path)
+	var tmpl *template.Template
+	if err == nil {
+		tmpl, err = template.New("root").Parse(string(content))
+	}
+	if err != nil {
+		serverLog.Errorf("error on loading template '%s', falling back to the embedded template: %s", path, err)
+		s.rootTmpl.Store(&rootTemplate{tmpl: s.embeddedTmpl, path: path, err: err})
+		return
+	}
+	serverLog.Infof("Loaded template '%s'", path)
+	s.rootTmpl.Store(&rootTemplate{tmpl: tmpl, path: path})
+}
+
+// watchRootTemplate reloads the template whenever the file or 'templatePath' changes.
+func (s *server) watchRootTemplate() {
+	watchFiles(func() []string { return []string{s.config.templatePath} }, s.loadRootTemplate)
+}
+
+// renderRootTemplate renders into a buffer first, so errors on executing a loaded template still
+// result in a complete page rendered by the embedded template.
+func (s *server) renderRootTemplate(data TemplateData) ([]byte, error) {
+	rootTmpl := s.rootTmpl.Load()
+	if rootTmpl.err != nil {
+		data.TemplateError = rootTmpl.err.Error()
+	}
+	var buf bytes.Buffer
+	err := rootTmpl.tmpl.Execute(&buf, data)
+	if err == nil || rootTmpl.tmpl == s.embeddedTmpl {
+		return buf.Bytes(), err
+	}
+
+	serverLog.Errorf("error on executing template '%s', falling back to the embedded template: %s", rootTmpl.path, err)
+	data.TemplateError = err.Error()
+	buf.Reset()
+	err = s.embeddedTmpl.Execute(&buf, data)
+	return buf.Bytes(), err
+}
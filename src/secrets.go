@@ -0,0 +1,103 @@
+package main
+
+import (
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/magiconair/properties"
+)
+
+const fileReferencePrefix = "file:"
+const secretsSectionPrefix = "secrets."
+const redactedValue = "<redacted>"
+
+type secretFile struct {
+	Name string
+	Path string
+}
+
+type secretInfo struct {
+	Name    string `json:"name"`
+	Path    string `json:"path"`
+	Value   string `json:"value,omitempty"`
+	ModTime string `json:"modTime,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// resolveFileReference replaces values of the form 'file:/path/to/file' with the content of
+// the referenced file. Properties resolved this way are treated as secrets and get redacted.
+func (appConfig *appConfig) resolveFileReference(property, value, defaultValue string) string {
+	path, isFileReference := strings.CutPrefix(value, fileReferencePrefix)
+	if !isFileReference {
+		return value
+	}
+	appConfig.loading.secretProperties[property] = true
+	content, err := os.ReadFile(path)
+	if err != nil {
+		configLog.Errorf("could not read file '%s' referenced by configuration property '%s': %v", path, property, err)
+		return defaultValue
+	}
+	configLog.Infof("Read configuration property '%s' from file '%s'", property, path)
+	return strings.TrimRight(string(content), "\r\n")
+}
+
+// isSecret reports if the property was read from a file reference on the last initialization.
+func (appConfig *appConfig) isSecret(property string) bool {
+	appConfig.mapsMutex.RLock()
+	defer appConfig.mapsMutex.RUnlock()
+	return appConfig.secretProperties[property]
+}
+
+// redact masks the value of properties which were read from a file reference, unless secrets are revealed.
+func (appConfig *appConfig) redact(property, value string) string {
+	if appConfig.isSecret(property) && !appConfig.revealSecrets {
+		return redactedValue
+	}
+	return value
+}
+
+func getSecretFiles(fileConfig *properties.Properties) []*secretFile {
+	if fileConfig == nil {
+		return nil
+	}
+	secrets := fileConfig.FilterStripPrefix(secretsSectionPrefix)
+	names := secrets.Keys()
+	sort.Strings(names)
+	secretFiles := make([]*secretFile, len(names))
+	for i, name := range names {
+		secretFiles[i] = &secretFile{
+			Name: name,
+			Path: secrets.GetString(name, ""),
+		}
+	}
+	return secretFiles
+}
+
+// readSecrets reads the configured secret files on every call, so rotated secrets show up without a restart.
+func (appConfig *appConfig) readSecrets() []*secretInfo {
+	secretInfos := make([]*secretInfo, len(appConfig.secretFiles))
+	for i, secret := range appConfig.secretFiles {
+		secretInfos[i] = &secretInfo{
+			Name: secret.Name,
+			Path: secret.Path,
+		}
+		fileInfo, err := os.Stat(secret.Path)
+		if err != nil {
+			secretInfos[i].Error = err.Error()
+			continue
+		}
+		content, err := os.ReadFile(secret.Path)
+		if err != nil {
+			secretInfos[i].Error = err.Error()
+			continue
+		}
+		secretInfos[i].ModTime = fileInfo.ModTime().Format(time.RFC3339)
+		secretInfos[i].Value = redactedValue
+		if appConfig.revealSecrets {
+			secretInfos[i].Value = strings.TrimRight(string(content), "\r\n")
+		}
+	}
+	return secretInfos
+}
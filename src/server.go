@@ -1,44 +1,78 @@
 package main
 
 import (
+	"context"
+	"crypto/tls"
 	_ "embed"
+	"errors"
 	"fmt"
 	"html/template"
+	"io"
+	"net"
 	"net/http"
+	"net/http/pprof"
 	"os"
 	"strconv"
+	"sync/atomic"
 	"time"
 
-	log "github.com/sirupsen/logrus"
+	"github.com/quic-go/quic-go/http3"
+	"google.golang.org/grpc"
 )
 
 //go:embed root.html
 var rootTmplContent string
 
 type server struct {
-	config *appConfig
-	mux    *http.ServeMux
-	tmpl   *template.Template
+	config           *appConfig
+	mux              *http.ServeMux
+	embeddedTmpl     *template.Template
+	rootTmpl         atomic.Pointer[rootTemplate]
+	httpServer       *http.Server
+	tlsServer        *http.Server
+	http3Server      *http3.Server
+	managementServer *http.Server
+	grpcServer       *grpc.Server
+	grpcHealthServer *grpcHealthServer
+	socketClosers    []io.Closer
+	appListener      appListener
+	certificate      atomic.Pointer[tls.Certificate]
+	inFlightRequests atomic.Int64
+	shuttingDown     atomic.Bool
+	metrics          *metrics
 }
 
 type TemplateData struct {
-	ApplicationPort      int
-	ApplicationName      string
-	ApplicationVersion   string
-	ApplicationMessage   string
-	Color                string
-	Alive                bool
-	Ready                bool
-	RootDelaySeconds     int
-	StartUpDelaySeconds  int
-	TearDownDelaySeconds int
-	RequestInfo          *requestInfo
-	LogToFileOnly        bool
-	PersistMetaInfo      bool
-	ProcessId            int
-	UserId               int
-	Hostname             string
-	CatImageURL          string
+	ApplicationPort      int              `json:"applicationPort"`
+	Listening            bool             `json:"listening"`
+	ManagementPort       int              `json:"managementPort"`
+	TlsPort              int              `json:"tlsPort"`
+	TlsCertificate       *servingCertInfo `json:"tlsCertificate"`
+	GrpcPort             int              `json:"grpcPort"`
+	SocketListeners      []string         `json:"socketListeners"`
+	ProxyProtocol        bool             `json:"proxyProtocol"`
+	TrustedProxies       []string         `json:"trustedProxies"`
+	ApplicationName      string           `json:"applicationName"`
+	ApplicationVersion   string           `json:"applicationVersion"`
+	ApplicationMessage   string           `json:"applicationMessage"`
+	Color                string           `json:"color"`
+	Alive                bool             `json:"alive"`
+	Ready                bool             `json:"ready"`
+	Started              bool             `json:"started"`
+	RootDelaySeconds     int              `json:"rootDelaySeconds"`
+	StartUpDelaySeconds  int              `json:"startUpDelaySeconds"`
+	StartUpProgress      int              `json:"startUpProgressSeconds"`
+	TearDownDelaySeconds int              `json:"tearDownDelaySeconds"`
+	RequestInfo          *requestInfo     `json:"requestInfo"`
+	LogToFileOnly        bool             `json:"logToFileOnly"`
+	PersistMetaInfo      bool             `json:"persistMetaInfo"`
+	ProcessId            int              `json:"processId"`
+	UserId               int              `json:"userId"`
+	Hostname             string           `json:"hostname"`
+	CatImageURL          string           `json:"catImageUrl"`
+	RevealSecrets        bool             `json:"revealSecrets"`
+	Secrets              []*secretInfo    `json:"secrets"`
+	TemplateError        string           `json:"templateError,omitempty"`
 }
 
 func newServer(appConfig *appConfig) *server {
@@ -46,69 +80,350 @@ func newServer(appConfig *appConfig) *server {
 	rootTmpl, err := template.New("root").Parse(rootTmplContent)
 
 	if err != nil {
-		log.Fatalf("Failed to parse template: %v", err)
+		serverLog.Fatalf("Failed to parse template: %v", err)
 	}
 
 	mux := http.NewServeMux()
 
 	server := &server{
-		config: appConfig,
-		mux:    mux,
-		tmpl:   rootTmpl,
+		config:       appConfig,
+		mux:          mux,
+		embeddedTmpl: rootTmpl,
+		metrics:      newMetrics(appConfig),
+	}
+	server.loadRootTemplate()
+	go server.watchRootTemplate()
+	server.httpServer = server.newHttpServer(appConfig.applicationPort, server.shapeNetwork(mux))
+	if appConfig.h2cEnabled {
+		server.httpServer.Protocols = newCleartextProtocols()
+	}
+	if appConfig.tlsEnabled() {
+		certificate, err := appConfig.loadCertificate()
+		if err != nil {
+			serverLog.Fatalf("Failed to load the TLS certificate: %v", err)
+		}
+		server.certificate.Store(certificate)
+		go server.watchCertificate()
+		server.tlsServer = server.newHttpServer(appConfig.tlsPort, server.shapeNetwork(mux))
+		server.tlsServer.TLSConfig, err = server.newTLSConfig()
+		if err != nil {
+			serverLog.Fatalf("Failed to configure TLS: %v", err)
+		}
+		if appConfig.http3Enabled {
+			server.http3Server = newHttp3Server(appConfig.tlsPort, server.tlsServer.Handler, server.tlsServer.TLSConfig)
+			server.tlsServer.Handler = server.advertiseHttp3(server.tlsServer.Handler)
+		}
+	} else if appConfig.http3Enabled {
+		serverLog.Warn("HTTP/3 is not served, as it requires TLS to be configured")
 	}
 
 	mux.HandleFunc("/", server.handleRoot)
 	mux.HandleFunc("/favicon.ico", server.handleFavicon)
-	mux.HandleFunc("/liveness", server.handleLiveness)
-	mux.HandleFunc("/readiness", server.handleReadiness)
+	mux.HandleFunc("/echo", server.handleEcho)
+	mux.HandleFunc("/echo/", server.handleEcho)
+	server.registerToolbox(mux)
+
+	if appConfig.grpcPort > 0 {
+		var err error
+		server.grpcServer, err = server.newGrpcServer()
+		if err != nil {
+			serverLog.Fatalf("Failed to create the gRPC server: %v", err)
+		}
+	}
+
+	// without a management port, probes, metrics and the api are served on the application port
+	managementMux := mux
+	if appConfig.managementPort > 0 {
+		managementMux = http.NewServeMux()
+		server.managementServer = server.newHttpServer(appConfig.managementPort, managementMux)
+		registerPprof(managementMux)
+		// otherwise the root endpoint would answer requests to the management endpoints on the application port
+		for _, pattern := range []string{"/startup", "/liveness", "/readiness", "/metrics", "/debug/pprof/", "/api/"} {
+			mux.HandleFunc(pattern, http.NotFound)
+		}
+	}
+	managementMux.HandleFunc("/startup", server.handleStartup)
+	managementMux.HandleFunc("/liveness", server.handleLiveness)
+	managementMux.HandleFunc("/readiness", server.handleReadiness)
+	managementMux.Handle("/metrics", server.metrics.handler())
+	managementMux.HandleFunc("GET /api/config", server.handleGetConfig)
+	managementMux.HandleFunc("PATCH /api/config", server.handlePatchConfig)
+	managementMux.HandleFunc("GET /api/config/history", server.handleGetConfigHistory)
+	managementMux.HandleFunc("GET /api/metrics", server.handleGetMetrics)
+	managementMux.HandleFunc("PUT /api/metrics/{name}", server.handlePutMetric)
 
 	return server
 }
 
+// tlsPort returns the port serving TLS, 0 if TLS is disabled.
+func (s *server) tlsPort() int {
+	if s.tlsServer == nil {
+		return 0
+	}
+	return s.config.tlsPort
+}
+
+func (s *server) newHttpServer(port int, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:        ":" + strconv.Itoa(port),
+		Handler:     withRequestId(s.withClientInfo(s.trackInFlight(s.accessLog(s.metrics.instrument(handler))))),
+		ConnContext: withConn,
+	}
+}
+
+func registerPprof(mux *http.ServeMux) {
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+}
+
+// httpServers returns the application server and, if configured, the TLS and the management server.
+func (s *server) httpServers() []*http.Server {
+	httpServers := []*http.Server{s.httpServer}
+	if s.tlsServer != nil {
+		httpServers = append(httpServers, s.tlsServer)
+	}
+	if s.managementServer != nil {
+		httpServers = append(httpServers, s.managementServer)
+	}
+	return httpServers
+}
+
 func (s *server) run() {
 	hostName, _ := os.Hostname()
-	log.Infof("Application started with PID %d, UID %d on host with name %s; listenting on port %d", os.Getpid(), os.Getuid(), hostName, config.applicationPort)
-	err := http.ListenAndServe(":"+strconv.Itoa(s.config.applicationPort), s.mux)
-	if err != nil {
-		log.Errorf("error on starting the server: '%s'", err)
+	serverLog.Infof("Application started with PID %d, UID %d on host with name %s; listenting on port %d", os.Getpid(), os.Getuid(), hostName, s.config.applicationPort)
+	if s.managementServer != nil {
+		serverLog.Infof("Serving probes, metrics, pprof and the api on management port %d", s.config.managementPort)
+		go s.serve(s.managementServer, false)
+	}
+	if s.tlsServer != nil {
+		serverLog.Infof("Serving TLS on port %d", s.config.tlsPort)
+		go s.serve(s.tlsServer, true)
+	}
+	if s.http3Server != nil {
+		serverLog.Infof("Serving HTTP/3 on UDP port %d", s.config.tlsPort)
+		go serveHttp3(s.http3Server)
+	}
+	if s.grpcServer != nil {
+		serverLog.Infof("Serving gRPC on port %d", s.config.grpcPort)
+		go s.serveGrpc()
+	}
+	if s.config.proxyProtocol {
+		serverLog.Info("Accepting PROXY protocol headers on the application port, the TLS port and the TCP socket listeners")
+	}
+	s.serveSockets()
+	if err := s.startListener(s.config.applicationPort); err != nil {
+		serverLog.Errorf("error on starting the server on port %d: '%s'", s.config.applicationPort, err)
+		os.Exit(1)
+	}
+}
+
+func serveHttp3(http3Server *http3.Server) {
+	err := http3Server.ListenAndServe()
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		serverLog.Errorf("error on starting the HTTP/3 server on '%s': '%s'", http3Server.Addr, err)
+		os.Exit(1)
+	}
+}
+
+// serve serves the TLS or the management server on a TCP listener, which optionally accepts PROXY protocol headers.
+func (s *server) serve(httpServer *http.Server, acceptsProxyProtocol bool) {
+	var listener net.Listener
+	var err error
+	if acceptsProxyProtocol {
+		listener, err = s.listen(httpServer.Addr)
+	} else {
+		listener, err = net.Listen("tcp", httpServer.Addr)
+	}
+	if err == nil {
+		if httpServer.TLSConfig != nil {
+			err = httpServer.ServeTLS(listener, "", "")
+		} else {
+			err = httpServer.Serve(listener)
+		}
+	}
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		serverLog.Errorf("error on starting the server on '%s': '%s'", httpServer.Addr, err)
+		os.Exit(1)
 	}
 }
 
+// shutdown runs the phases of the graceful shutdown and returns the exit code of the application:
+// the application stays unready for 'tearDownDelaySeconds', then stops accepting new connections and
+// drains the requests in flight for at most 'drainTimeoutSeconds'.
+func (s *server) shutdown() int {
+	s.shuttingDown.Store(true)
+	s.config.ready = false
+	serverLog.Info("Application set to not ready")
+	if s.grpcHealthServer != nil {
+		s.grpcHealthServer.startShutdown()
+	}
+	serverLog.Info("Starting Graceful Shutdown")
+	for i := 0; i < s.config.tearDownDelaySeconds; i++ {
+		time.Sleep(1 * time.Second)
+		serverLog.Infof("Graceful shutdown took %d seconds of %d seconds, %d requests in flight", i+1, s.config.tearDownDelaySeconds, s.inFlightRequests.Load())
+	}
+
+	s.closeSockets()
+	serverLog.Infof("Stopped accepting new connections, draining %d requests in flight for at most %d seconds", s.inFlightRequests.Load(), s.config.drainTimeoutSeconds)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(s.config.drainTimeoutSeconds)*time.Second)
+	defer cancel()
+	shutdownResult := make(chan error, 1)
+	go func() {
+		shutdownResult <- s.shutdownHttpServers(ctx)
+	}()
+
+	ticker := time.NewTicker(1 * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case err := <-shutdownResult:
+			if err != nil {
+				serverLog.Errorf("error on draining, closing %d requests in flight: %s", s.inFlightRequests.Load(), err)
+				for _, httpServer := range s.httpServers() {
+					if err := httpServer.Close(); err != nil {
+						serverLog.Errorf("error on closing: %v", err)
+					}
+				}
+				if s.http3Server != nil {
+					if err := s.http3Server.Close(); err != nil {
+						serverLog.Errorf("error on closing: %v", err)
+					}
+				}
+				return 1
+			}
+			serverLog.Info("Drained all requests in flight")
+			serverLog.Info("Graceful Shutdown has finished")
+			return 0
+		case <-ticker.C:
+			serverLog.Infof("Draining, %d requests in flight", s.inFlightRequests.Load())
+		}
+	}
+}
+
+// shutdownHttpServers shuts down the application, TLS, HTTP/3 and gRPC servers at the same time and the management
+// server only after they are drained, so it keeps answering probes during the drain.
+func (s *server) shutdownHttpServers(ctx context.Context) error {
+	shutdowns := []func(context.Context) error{}
+	for _, httpServer := range s.httpServers() {
+		if httpServer != s.managementServer {
+			shutdowns = append(shutdowns, httpServer.Shutdown)
+		}
+	}
+	if s.http3Server != nil {
+		shutdowns = append(shutdowns, s.http3Server.Shutdown)
+	}
+	if s.grpcServer != nil {
+		shutdowns = append(shutdowns, s.shutdownGrpc)
+	}
+	results := make(chan error, len(shutdowns))
+	for _, shutdown := range shutdowns {
+		go func() {
+			results <- shutdown(ctx)
+		}()
+	}
+	var errs []error
+	for range shutdowns {
+		errs = append(errs, <-results)
+	}
+	if s.managementServer != nil {
+		errs = append(errs, s.managementServer.Shutdown(ctx))
+	}
+	return errors.Join(errs...)
+}
+
+func (s *server) trackInFlight(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.inFlightRequests.Add(1)
+		defer s.inFlightRequests.Add(-1)
+		next.ServeHTTP(w, r)
+	})
+}
+
 func (s *server) handleRoot(w http.ResponseWriter, r *http.Request) {
-	log.Info("Request to root endpoint ('/')")
+	requestLog(r).Info("Request to root endpoint ('/')")
 	requestInfo := newRequestInfo(r)
-	log.Info(requestInfo)
+	requestLog(r).Debug(requestInfo)
+
+	w.Header().Set("Vary", "Accept")
+	format, err := negotiateFormat(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		requestLog(r).Infof("Root endpoint ('/') responded with Status Code 400 Bad Request: %s", err)
+		return
+	}
 
 	if !s.config.rootEnabled {
 		w.WriteHeader(http.StatusServiceUnavailable)
 		_, err := fmt.Fprint(w, "The root endpoint of the application is disabled")
 		if err != nil {
-			log.Errorf("error on writing response for root endpoint ('/'): %s", err)
+			requestLog(r).Errorf("error on writing response for root endpoint ('/'): %s", err)
 		}
-		log.Info("Root endpoint ('/') responded with Status Code 503 Service Unavailable due to root endpoint is disabled")
+		requestLog(r).Info("Root endpoint ('/') responded with Status Code 503 Service Unavailable due to root endpoint is disabled")
 		return
 	}
 
 	if s.config.rootDelaySeconds > 0 {
 		for i := 0; i < s.config.rootDelaySeconds; i++ {
-			log.Infof("Delayed Response for %d of %d seconds", i+1, s.config.rootDelaySeconds)
+			requestLog(r).Infof("Delayed Response for %d of %d seconds", i+1, s.config.rootDelaySeconds)
 			time.Sleep(1 * time.Second)
 		}
-		log.Info("Finished delaying Response")
+		requestLog(r).Info("Finished delaying Response")
 	}
 
+	data := s.newTemplateData(requestInfo)
+
+	switch format {
+	case formatJSON:
+		writeJSON(w, http.StatusOK, data)
+	case formatText:
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, err := fmt.Fprintf(w, "%s %s on %s: %s\n", data.ApplicationName, data.ApplicationVersion, data.Hostname, data.ApplicationMessage)
+		if err != nil {
+			requestLog(r).Errorf("error on writing response for root endpoint ('/'): %s", err)
+		}
+	default:
+		page, err := s.renderRootTemplate(data)
+		if err != nil {
+			requestLog(r).Errorf("error executing template: %v", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		if _, err := w.Write(page); err != nil {
+			requestLog(r).Errorf("error on writing response for root endpoint ('/'): %s", err)
+		}
+	}
+}
+
+// newTemplateData collects the data shown by the root endpoint, which is also served by the gRPC Info service.
+func (s *server) newTemplateData(requestInfo *requestInfo) TemplateData {
 	hostname, _ := os.Hostname()
+	valueData := newValueTemplateData(s.config, requestInfo.Header)
+	listening, applicationPort := s.listening()
 
-	data := TemplateData{
-		ApplicationPort:      s.config.applicationPort,
-		ApplicationName:      s.config.applicationName,
-		ApplicationVersion:   s.config.applicationVersion,
-		ApplicationMessage:   s.config.applicationMessage,
-		Color:                s.config.color,
+	return TemplateData{
+		ApplicationPort:      applicationPort,
+		Listening:            listening,
+		ManagementPort:       s.config.managementPort,
+		TlsPort:              s.tlsPort(),
+		TlsCertificate:       newServingCertInfo(s.certificate.Load()),
+		GrpcPort:             s.config.grpcPort,
+		SocketListeners:      s.config.socketListenerNames(),
+		ProxyProtocol:        s.config.proxyProtocol,
+		TrustedProxies:       s.config.trustedProxyNames(),
+		ApplicationName:      s.config.redact("name", s.config.renderValue("name", s.config.applicationName, valueData)),
+		ApplicationVersion:   s.config.redact("version", s.config.applicationVersion),
+		ApplicationMessage:   s.config.redact("message", s.config.renderValue("message", s.config.applicationMessage, valueData)),
+		Color:                s.config.redact("color", s.config.renderValue("color", s.config.color, valueData)),
 		Alive:                s.config.alive,
 		Ready:                s.config.ready,
+		Started:              s.config.started,
 		RootDelaySeconds:     s.config.rootDelaySeconds,
 		StartUpDelaySeconds:  s.config.startUpDelaySeconds,
+		StartUpProgress:      s.config.startUpProgressSeconds,
 		TearDownDelaySeconds: s.config.tearDownDelaySeconds,
 		LogToFileOnly:        s.config.logToFileOnly,
 		PersistMetaInfo:      s.config.persistMetaInfo,
@@ -117,13 +432,8 @@ func (s *server) handleRoot(w http.ResponseWriter, r *http.Request) {
 		RequestInfo:          requestInfo,
 		Hostname:             hostname,
 		CatImageURL:          s.config.catImageUrl,
-	}
-
-	w.Header().Set("Content-Type", "text/html")
-	if err := s.tmpl.Execute(w, data); err != nil {
-		log.Errorf("error executing template: %v", err)
-		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
-		return
+		RevealSecrets:        s.config.revealSecrets,
+		Secrets:              s.config.readSecrets(),
 	}
 }
 
@@ -131,26 +441,38 @@ func (s *server) handleFavicon(w http.ResponseWriter, r *http.Request) {
 	http.NotFound(w, r)
 }
 
+func (s *server) handleStartup(w http.ResponseWriter, r *http.Request) {
+	requestLog(r).Info("Request to startup endpoint ('/startup')")
+
+	if s.config.started {
+		w.WriteHeader(http.StatusOK)
+		requestLog(r).Info("Startup endpoint ('/startup') responded with Status Code 200 OK")
+	} else {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		requestLog(r).Info("Startup endpoint ('/startup') responded with Status Code 503 Service Unavailable")
+	}
+}
+
 func (s *server) handleLiveness(w http.ResponseWriter, r *http.Request) {
-	log.Info("Request to liveness endpoint ('/liveness')")
+	requestLog(r).Info("Request to liveness endpoint ('/liveness')")
 
 	if s.config.alive {
 		w.WriteHeader(http.StatusOK)
-		log.Info("Liveness endpoint ('/liveness') responded with Status Code 200 OK")
+		requestLog(r).Info("Liveness endpoint ('/liveness') responded with Status Code 200 OK")
 	} else {
 		w.WriteHeader(http.StatusInternalServerError)
-		log.Info("Liveness endpoint ('/liveness') responded with Status Code 500 Internal Server Error")
+		requestLog(r).Info("Liveness endpoint ('/liveness') responded with Status Code 500 Internal Server Error")
 	}
 }
 
 func (s *server) handleReadiness(w http.ResponseWriter, r *http.Request) {
-	log.Info("Request to readiness endpoint ('/readiness')")
+	requestLog(r).Info("Request to readiness endpoint ('/readiness')")
 
 	if s.config.ready {
 		w.WriteHeader(http.StatusOK)
-		log.Info("Readiness endpoint ('/readiness') responded with Status Code 200 OK")
+		requestLog(r).Info("Readiness endpoint ('/readiness') responded with Status Code 200 OK")
 	} else {
 		w.WriteHeader(http.StatusServiceUnavailable)
-		log.Info("Readiness endpoint ('/readiness') responded with Status Code 503 Service Unavailable")
+		requestLog(r).Info("Readiness endpoint ('/readiness') responded with Status Code 503 Service Unavailable")
 	}
 }
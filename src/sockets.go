@@ -0,0 +1,188 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pires/go-proxyproto"
+)
+
+const udpMaxDatagramBytes = 65535
+
+// socketProxyHeaderTimeout is how long the TCP socket listeners wait for an optional PROXY protocol header. Unlike
+// HTTP, the server sends first, so clients without a header would otherwise wait for the default of 10 seconds.
+const socketProxyHeaderTimeout = 200 * time.Millisecond
+
+var socketNetworks = []string{"tcp", "udp"}
+var socketModes = []string{"echo", "banner"}
+
+// socketListener is a plain TCP or UDP listener, configured as '<network>:<port>:<mode>', eg 'udp:5353:echo'.
+// In mode 'echo' it sends back everything it receives, in mode 'banner' it responds with a single line and,
+// for TCP, closes the connection. Both report the hostname and the client address.
+type socketListener struct {
+	network string
+	port    int
+	mode    string
+}
+
+func (sl *socketListener) String() string {
+	return fmt.Sprintf("%s:%d:%s", sl.network, sl.port, sl.mode)
+}
+
+func (appConfig *appConfig) socketListenerNames() []string {
+	names := []string{}
+	for _, socketListener := range appConfig.socketListeners {
+		names = append(names, socketListener.String())
+	}
+	return names
+}
+
+func parseSocketListener(value string) (*socketListener, error) {
+	parts := strings.Split(value, ":")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("invalid socket listener '%s', use '<network>:<port>:<mode>', eg 'tcp:9000:echo'", value)
+	}
+	if !slices.Contains(socketNetworks, parts[0]) {
+		return nil, fmt.Errorf("unknown network '%s' of socket listener '%s', use one of '%s'", parts[0], value, strings.Join(socketNetworks, "', '"))
+	}
+	port, err := strconv.Atoi(parts[1])
+	if err != nil || port < 1 || port > 65535 {
+		return nil, fmt.Errorf("invalid port '%s' of socket listener '%s'", parts[1], value)
+	}
+	if !slices.Contains(socketModes, parts[2]) {
+		return nil, fmt.Errorf("unknown mode '%s' of socket listener '%s', use one of '%s'", parts[2], value, strings.Join(socketModes, "', '"))
+	}
+	return &socketListener{network: parts[0], port: port, mode: parts[2]}, nil
+}
+
+// parseSocketListeners parses the comma separated list of socket listeners, invalid ones are skipped.
+func parseSocketListeners(value string) []*socketListener {
+	socketListeners := []*socketListener{}
+	for _, item := range splitList(value) {
+		socketListener, err := parseSocketListener(item)
+		if err != nil {
+			configLog.Errorf("could not configure socket listener: %s", err)
+			continue
+		}
+		socketListeners = append(socketListeners, socketListener)
+	}
+	return socketListeners
+}
+
+// banner is the text format of the root endpoint including the client address, with templated and redacted values.
+func (s *server) banner(clientAddr net.Addr) string {
+	hostname, _ := os.Hostname()
+	valueData := newValueTemplateData(s.config, http.Header{})
+	name := s.config.redact("name", s.config.renderValue("name", s.config.applicationName, valueData))
+	version := s.config.redact("version", s.config.applicationVersion)
+	message := s.config.redact("message", s.config.renderValue("message", s.config.applicationMessage, valueData))
+	return fmt.Sprintf("%s %s on %s, client %s: %s\n", name, version, hostname, clientAddr, message)
+}
+
+// serveSockets opens the socket listeners, which are closed again on shutdown.
+func (s *server) serveSockets() {
+	for _, socketListener := range s.config.socketListeners {
+		address := ":" + strconv.Itoa(socketListener.port)
+		var closer io.Closer
+		var err error
+		if socketListener.network == "tcp" {
+			var listener net.Listener
+			listener, err = s.listen(address)
+			if proxyListener, isProxyListener := listener.(*proxyproto.Listener); isProxyListener {
+				proxyListener.ReadHeaderTimeout = socketProxyHeaderTimeout
+			}
+			if err == nil {
+				closer = listener
+				go s.serveTcp(listener, socketListener.mode)
+			}
+		} else {
+			var conn net.PacketConn
+			conn, err = net.ListenPacket("udp", address)
+			if err == nil {
+				closer = conn
+				go s.serveUdp(conn, socketListener.mode)
+			}
+		}
+		if err != nil {
+			socketLog.Errorf("error on starting socket listener '%s': '%s'", socketListener, err)
+			os.Exit(1)
+		}
+		socketLog.Infof("Serving %s on %s port %d", socketListener.mode, strings.ToUpper(socketListener.network), socketListener.port)
+		s.socketClosers = append(s.socketClosers, closer)
+	}
+}
+
+func (s *server) closeSockets() {
+	for _, closer := range s.socketClosers {
+		if err := closer.Close(); err != nil {
+			socketLog.Errorf("error on closing: %v", err)
+		}
+	}
+}
+
+func (s *server) serveTcp(listener net.Listener, mode string) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				socketLog.Errorf("error on accepting connection on '%s': %s", listener.Addr(), err)
+			}
+			return
+		}
+		go s.handleTcp(conn, mode)
+	}
+}
+
+func (s *server) handleTcp(conn net.Conn, mode string) {
+	defer func() {
+		if err := conn.Close(); err != nil {
+			socketLog.Errorf("error on closing: %v", err)
+		}
+	}()
+	socketLog.Infof("TCP connection from '%s' to '%s' (%s)", conn.RemoteAddr(), conn.LocalAddr(), mode)
+
+	if _, err := io.WriteString(conn, s.banner(conn.RemoteAddr())); err != nil || mode == "banner" {
+		return
+	}
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		if _, err := fmt.Fprintln(conn, scanner.Text()); err != nil {
+			socketLog.Errorf("error on echoing to '%s': %s", conn.RemoteAddr(), err)
+			return
+		}
+	}
+	socketLog.Infof("TCP connection from '%s' closed", conn.RemoteAddr())
+}
+
+// serveUdp responds to every datagram, in mode 'echo' with the datagram prefixed by hostname and client address.
+func (s *server) serveUdp(conn net.PacketConn, mode string) {
+	buffer := make([]byte, udpMaxDatagramBytes)
+	hostname, _ := os.Hostname()
+	for {
+		n, clientAddr, err := conn.ReadFrom(buffer)
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				socketLog.Errorf("error on reading from '%s': %s", conn.LocalAddr(), err)
+			}
+			return
+		}
+		socketLog.Infof("UDP datagram of %d bytes from '%s' to '%s' (%s)", n, clientAddr, conn.LocalAddr(), mode)
+
+		response := s.banner(clientAddr)
+		if mode == "echo" {
+			response = fmt.Sprintf("%s %s: %s", hostname, clientAddr, buffer[:n])
+		}
+		if _, err := conn.WriteTo([]byte(response), clientAddr); err != nil {
+			socketLog.Errorf("error on responding to '%s': %s", clientAddr, err)
+		}
+	}
+}
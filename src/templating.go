@@ -0,0 +1,76 @@
+package main
+
+import (
+	"net/http"
+	"os"
+	"strings"
+	"text/template"
+)
+
+type valueTemplateData struct {
+	Hostname  string
+	PodName   string
+	Namespace string
+	Version   string
+	Header    http.Header
+	Env       map[string]string
+}
+
+func newValueTemplateData(appConfig *appConfig, header http.Header) *valueTemplateData {
+	metadata := newPodMetadata()
+	env := map[string]string{}
+	for _, envVar := range os.Environ() {
+		name, value, _ := strings.Cut(envVar, "=")
+		env[name] = value
+	}
+	return &valueTemplateData{
+		Hostname:  metadata.Hostname,
+		PodName:   metadata.PodName,
+		Namespace: metadata.Namespace,
+		Version:   appConfig.redact("version", appConfig.applicationVersion),
+		Header:    header,
+		Env:       env,
+	}
+}
+
+// parseValueTemplates parses the values of the templated properties, values without template actions are skipped
+// as well as secrets read from a file reference, which are never executed as template. The templates replace the
+// current ones at once, as requests render them concurrently.
+func (appConfig *appConfig) parseValueTemplates() {
+	valueTemplates := map[string]*template.Template{}
+	values := map[string]string{
+		"name":    appConfig.applicationName,
+		"message": appConfig.applicationMessage,
+		"color":   appConfig.color,
+	}
+	for property, value := range values {
+		if !strings.Contains(value, "{{") || appConfig.isSecret(property) {
+			continue
+		}
+		tmpl, err := template.New(property).Option("missingkey=zero").Parse(value)
+		if err != nil {
+			configLog.Errorf("could not parse the value of configuration property '%s' as template, using it as is: %v", property, err)
+			continue
+		}
+		valueTemplates[property] = tmpl
+	}
+	appConfig.mapsMutex.Lock()
+	appConfig.valueTemplates = valueTemplates
+	appConfig.mapsMutex.Unlock()
+}
+
+// renderValue renders the value of a templated property, if rendering fails the value is used as is.
+func (appConfig *appConfig) renderValue(property, value string, data *valueTemplateData) string {
+	appConfig.mapsMutex.RLock()
+	tmpl, isTemplate := appConfig.valueTemplates[property]
+	appConfig.mapsMutex.RUnlock()
+	if !isTemplate {
+		return value
+	}
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, data); err != nil {
+		configLog.Errorf("could not render the value of configuration property '%s': %v", property, err)
+		return value
+	}
+	return sb.String()
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const selfSignedValidity = 365 * 24 * time.Hour
+
+var tlsClientAuthModes = map[string]tls.ClientAuthType{
+	"none":    tls.NoClientCert,
+	"request": tls.VerifyClientCertIfGiven,
+	"require": tls.RequireAndVerifyClientCert,
+}
+
+var selfSignedCaFilePath = filepath.Join(os.TempDir(), "training-application-ca.crt")
+
+func (appConfig *appConfig) tlsEnabled() bool {
+	return appConfig.tlsSelfSigned || appConfig.tlsCertFile != "" || appConfig.tlsKeyFile != ""
+}
+
+// newTLSConfig configures the client authentication according to 'tlsClientAuth': 'request' asks for a client
+// certificate and verifies it if given, 'require' rejects clients without a valid certificate. Without a
+// 'tlsClientCAFile', 'request' accepts any client certificate without verifying it.
+func (s *server) newTLSConfig() (*tls.Config, error) {
+	clientAuth, known := tlsClientAuthModes[s.config.tlsClientAuth]
+	if !known {
+		return nil, fmt.Errorf("unknown client auth mode '%s', use one of 'none', 'request' or 'require'", s.config.tlsClientAuth)
+	}
+	tlsConfig := &tls.Config{
+		GetCertificate: s.getCertificate,
+		ClientAuth:     clientAuth,
+	}
+	if clientAuth == tls.NoClientCert {
+		return tlsConfig, nil
+	}
+
+	if s.config.tlsClientCAFile == "" {
+		if clientAuth == tls.RequireAndVerifyClientCert {
+			return nil, errors.New("client auth mode 'require' needs a 'tlsClientCAFile'")
+		}
+		tlsConfig.ClientAuth = tls.RequestClientCert
+		return tlsConfig, nil
+	}
+	clientCAs, err := loadCertPool(s.config.tlsClientCAFile)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig.ClientCAs = clientCAs
+	return tlsConfig, nil
+}
+
+func loadCertPool(path string) (*x509.CertPool, error) {
+	pemCerts, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	certPool := x509.NewCertPool()
+	if !certPool.AppendCertsFromPEM(pemCerts) {
+		return nil, fmt.Errorf("no PEM encoded certificates found in '%s'", path)
+	}
+	return certPool, nil
+}
+
+// loadCertificate loads the certificate from 'tlsCertFile' and 'tlsKeyFile' or, in self-signed mode,
+// generates a CA and a server certificate signed by it.
+func (appConfig *appConfig) loadCertificate() (*tls.Certificate, error) {
+	if appConfig.tlsCertFile != "" || appConfig.tlsKeyFile != "" {
+		if appConfig.tlsCertFile == "" || appConfig.tlsKeyFile == "" {
+			return nil, errors.New("both 'tlsCertFile' and 'tlsKeyFile' have to be set")
+		}
+		cert, err := tls.LoadX509KeyPair(appConfig.tlsCertFile, appConfig.tlsKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		return &cert, nil
+	}
+	return generateSelfSignedCertificate(appConfig.tlsSANs)
+}
+
+// generateSelfSignedCertificate generates a CA, written to 'selfSignedCaFilePath' so clients can trust it,
+// and a server certificate for the hostname, localhost and the given SANs, which are either DNS names or IPs.
+func generateSelfSignedCertificate(sans []string) (*tls.Certificate, error) {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          newSerialNumber(),
+		Subject:               pkix.Name{CommonName: "Training Application CA"},
+		NotBefore:             time.Now().Add(-time.Minute),
+		NotAfter:              time.Now().Add(selfSignedValidity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	caDer, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		return nil, err
+	}
+	caCert, err := x509.ParseCertificate(caDer)
+	if err != nil {
+		return nil, err
+	}
+
+	hostname, _ := os.Hostname()
+	serverKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	serverTemplate := &x509.Certificate{
+		SerialNumber: newSerialNumber(),
+		Subject:      pkix.Name{CommonName: hostname},
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(selfSignedValidity),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		DNSNames:     []string{hostname, "localhost"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
+	}
+	for _, san := range sans {
+		if ip := net.ParseIP(san); ip != nil {
+			serverTemplate.IPAddresses = append(serverTemplate.IPAddresses, ip)
+		} else {
+			serverTemplate.DNSNames = append(serverTemplate.DNSNames, san)
+		}
+	}
+	serverDer, err := x509.CreateCertificate(rand.Reader, serverTemplate, caCert, &serverKey.PublicKey, caKey)
+	if err != nil {
+		return nil, err
+	}
+	serverCert, err := x509.ParseCertificate(serverDer)
+	if err != nil {
+		return nil, err
+	}
+
+	caPem := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDer})
+	if err := os.WriteFile(selfSignedCaFilePath, caPem, 0644); err != nil {
+		serverLog.Errorf("error on writing the self-signed CA to '%s': %s", selfSignedCaFilePath, err)
+	} else {
+		serverLog.Infof("Generated self-signed CA, written to '%s'", selfSignedCaFilePath)
+	}
+	serverLog.Infof("Generated self-signed certificate for %v %v", serverTemplate.DNSNames, serverTemplate.IPAddresses)
+
+	return &tls.Certificate{
+		Certificate: [][]byte{serverDer, caDer},
+		PrivateKey:  serverKey,
+		Leaf:        serverCert,
+	}, nil
+}
+
+func newSerialNumber() *big.Int {
+	serialNumber, _ := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	return serialNumber
+}
+
+// watchCertificate switches to the certificate in 'tlsCertFile' and 'tlsKeyFile' as soon as they change,
+// eg when cert-manager renews it. A broken certificate is logged and the current one stays in use.
+func (s *server) watchCertificate() {
+	watchFiles(func() []string { return []string{s.config.tlsCertFile, s.config.tlsKeyFile} }, func() {
+		if s.config.tlsCertFile == "" {
+			return
+		}
+		certificate, err := s.config.loadCertificate()
+		if err != nil {
+			serverLog.Errorf("error on reloading the TLS certificate, keeping the current one: %s", err)
+			return
+		}
+		s.certificate.Store(certificate)
+		info := newServingCertInfo(certificate)
+		serverLog.Infof("Reloaded the TLS certificate with serial %s, valid until %s", info.Serial, info.NotAfter)
+	})
+}
+
+type servingCertInfo struct {
+	Subject   string `json:"subject"`
+	Serial    string `json:"serial"`
+	NotBefore string `json:"notBefore"`
+	NotAfter  string `json:"notAfter"`
+}
+
+func newServingCertInfo(certificate *tls.Certificate) *servingCertInfo {
+	if certificate == nil || certificate.Leaf == nil {
+		return nil
+	}
+	return &servingCertInfo{
+		Subject:   certificate.Leaf.Subject.CommonName,
+		Serial:    certificate.Leaf.SerialNumber.Text(16),
+		NotBefore: certificate.Leaf.NotBefore.Format(time.RFC3339),
+		NotAfter:  certificate.Leaf.NotAfter.Format(time.RFC3339),
+	}
+}
+
+// getCertificate serves the current certificate, which allows to swap it without restarting the server.
+func (s *server) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return s.certificate.Load(), nil
+}
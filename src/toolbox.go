@@ -0,0 +1,149 @@
+package main
+
+import (
+	"crypto/rand"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+)
+
+const (
+	toolboxMaxDelay       = 60 * time.Second
+	toolboxMaxBytes       = 10 * 1024 * 1024
+	toolboxMaxStreamLines = 100
+	toolboxMaxRedirects   = 20
+)
+
+// registerToolbox registers httpbin-like endpoints, which respond with 404 unless 'toolboxEnabled' is set.
+func (s *server) registerToolbox(mux *http.ServeMux) {
+	mux.HandleFunc("/status/{code}", s.toolbox(s.handleStatus))
+	mux.HandleFunc("/delay/{duration}", s.toolbox(s.handleDelay))
+	mux.HandleFunc("/bytes/{n}", s.toolbox(s.handleBytes))
+	mux.HandleFunc("/stream/{n}", s.toolbox(s.handleStream))
+	mux.HandleFunc("/redirect/{n}", s.toolbox(s.handleRedirect))
+	mux.HandleFunc("/headers", s.toolbox(s.handleHeaders))
+	mux.HandleFunc("/ip", s.toolbox(s.handleIp))
+	mux.HandleFunc("/cookies", s.toolbox(s.handleCookies))
+	mux.HandleFunc("/cookies/set", s.toolbox(s.handleSetCookies))
+}
+
+func (s *server) toolbox(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !s.config.toolboxEnabled {
+			http.NotFound(w, r)
+			return
+		}
+		requestLog(r).Infof("Request to toolbox endpoint ('%s')", r.URL.Path)
+		handler(w, r)
+	}
+}
+
+func (s *server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	code, err := strconv.Atoi(r.PathValue("code"))
+	if err != nil || code < 100 || code > 599 {
+		http.Error(w, fmt.Sprintf("invalid status code '%s'", r.PathValue("code")), http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(code)
+}
+
+// handleDelay delays the response for the given duration, either in seconds (eg '3') or as Go duration (eg '1500ms').
+func (s *server) handleDelay(w http.ResponseWriter, r *http.Request) {
+	duration, err := parseSecondsOrDuration(r.PathValue("duration"))
+	if err != nil || duration < 0 || duration > toolboxMaxDelay {
+		http.Error(w, fmt.Sprintf("invalid delay '%s', maximum is %s", r.PathValue("duration"), toolboxMaxDelay), http.StatusBadRequest)
+		return
+	}
+	select {
+	case <-time.After(duration):
+	case <-r.Context().Done():
+		requestLog(r).Infof("Client canceled the delayed request after %s", duration)
+		return
+	}
+	writeJSON(w, http.StatusOK, newRequestInfo(r))
+}
+
+func (s *server) handleBytes(w http.ResponseWriter, r *http.Request) {
+	n, err := strconv.Atoi(r.PathValue("n"))
+	if err != nil || n < 0 || n > toolboxMaxBytes {
+		http.Error(w, fmt.Sprintf("invalid number of bytes '%s', maximum is %d", r.PathValue("n"), toolboxMaxBytes), http.StatusBadRequest)
+		return
+	}
+	bytes := make([]byte, n)
+	_, _ = rand.Read(bytes)
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Length", strconv.Itoa(n))
+	if _, err := w.Write(bytes); err != nil {
+		requestLog(r).Errorf("error on writing response: %s", err)
+	}
+}
+
+// handleStream streams n lines of JSON, flushing after every line.
+func (s *server) handleStream(w http.ResponseWriter, r *http.Request) {
+	n, err := strconv.Atoi(r.PathValue("n"))
+	if err != nil || n < 0 || n > toolboxMaxStreamLines {
+		http.Error(w, fmt.Sprintf("invalid number of lines '%s', maximum is %d", r.PathValue("n"), toolboxMaxStreamLines), http.StatusBadRequest)
+		return
+	}
+	hostname, _ := os.Hostname()
+	responseController := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "application/json")
+	for i := 0; i < n; i++ {
+		_, err := fmt.Fprintf(w, "{\"id\": %d, \"hostname\": %q, \"url\": %q}\n", i, hostname, r.URL.String())
+		if err == nil {
+			err = responseController.Flush()
+		}
+		if err != nil {
+			requestLog(r).Errorf("error on streaming response: %s", err)
+			return
+		}
+	}
+}
+
+// handleRedirect redirects n times before ending up at the echo endpoint.
+func (s *server) handleRedirect(w http.ResponseWriter, r *http.Request) {
+	n, err := strconv.Atoi(r.PathValue("n"))
+	if err != nil || n < 1 || n > toolboxMaxRedirects {
+		http.Error(w, fmt.Sprintf("invalid number of redirects '%s', maximum is %d", r.PathValue("n"), toolboxMaxRedirects), http.StatusBadRequest)
+		return
+	}
+	if n == 1 {
+		http.Redirect(w, r, "/echo", http.StatusFound)
+		return
+	}
+	http.Redirect(w, r, "/redirect/"+strconv.Itoa(n-1), http.StatusFound)
+}
+
+func (s *server) handleHeaders(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{"headers": r.Header})
+}
+
+// handleIp responds with the IP of the client, resolved via 'trustedProxies'.
+func (s *server) handleIp(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{"origin": newRequestInfo(r).ClientIp})
+}
+
+func (s *server) handleCookies(w http.ResponseWriter, r *http.Request) {
+	cookies := map[string]string{}
+	for _, cookie := range r.Cookies() {
+		cookies[cookie.Name] = cookie.Value
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"cookies": cookies})
+}
+
+// handleSetCookies sets the query parameters as cookies and redirects to the cookies endpoint.
+func (s *server) handleSetCookies(w http.ResponseWriter, r *http.Request) {
+	for name, values := range r.URL.Query() {
+		http.SetCookie(w, &http.Cookie{Name: name, Value: values[0], Path: "/"})
+	}
+	http.Redirect(w, r, "/cookies", http.StatusFound)
+}
+
+func parseSecondsOrDuration(value string) (time.Duration, error) {
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+		return time.Duration(seconds * float64(time.Second)), nil
+	}
+	return time.ParseDuration(value)
+}
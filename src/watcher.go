@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+const fileWatchInterval = 2 * time.Second
+
+// watchFiles polls the files returned by paths and calls onChange as soon as one of them got created,
+// modified or removed, or the paths themselves changed. Polling instead of inotify also catches
+// ConfigMaps and Secrets, which Kubernetes updates by swapping symlinks.
+func watchFiles(paths func() []string, onChange func()) {
+	last := fileFingerprint(paths())
+	ticker := time.NewTicker(fileWatchInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		current := fileFingerprint(paths())
+		if current != last {
+			last = current
+			onChange()
+		}
+	}
+}
+
+func fileFingerprint(paths []string) string {
+	var sb strings.Builder
+	for _, path := range paths {
+		sb.WriteString(path)
+		if info, err := os.Stat(path); err == nil {
+			sb.WriteString(fmt.Sprintf(":%d:%d", info.ModTime().UnixNano(), info.Size()))
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}